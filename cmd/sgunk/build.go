@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/spf13/afero"
+)
+
+var buildCommand = &command{
+	name:  "build",
+	usage: "build [flags]",
+	short: "Build the project into its build directory",
+	run:   runBuild,
+}
+
+func runBuild(cmd *command, args []string) error {
+	var pf projectFlags
+	flags := newFlagSet(cmd)
+	pf.register(flags)
+	dryRun := flags.Bool("dry-run", false, "render into memory and list the files that would be written")
+	incremental := flags.Bool("incremental", true, "only re-render outputs whose inputs changed since the last build")
+	full := flags.Bool("full", false, "re-render every output, ignoring the last build")
+	timeout := flags.Duration("timeout", 0, "abort the build if it takes longer than this, e.g. 2m")
+	flags.Parse(args)
+	pf.setupLogging()
+
+	p, err := pf.project(
+		sgunk.WithDryRun(*dryRun),
+		sgunk.WithIncremental(*incremental && !*full),
+	)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := buildContext(*timeout)
+	defer cancel()
+
+	start := time.Now()
+	err = p.Generate(ctx)
+	pf.reportSkipped(p)
+	if err := pf.reportDiagnostics(p, err); err != nil {
+		return err
+	}
+
+	switch {
+	case *dryRun:
+		err = walkFiles(p.BuildFS(), func(path string) {
+			fmt.Printf("would write %s\n", path)
+		})
+	case pf.verbose:
+		err = walkFiles(p.BuildFS(), func(path string) {
+			pf.verbosef("wrote %s", path)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	stats := p.RenderStats()
+	pf.verbosef("built %s in %s (%d rendered, %d reused)", p.BuildDir(), time.Since(start).Round(time.Millisecond), stats.Rendered, stats.Reused)
+	return nil
+}
+
+// walkFiles calls fn with the path of every regular file in fsys.
+func walkFiles(fsys afero.Fs, fn func(path string)) error {
+	return afero.Walk(fsys, ".", func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			fn(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/connormckelvey/sgunk"
+)
+
+var checkCommand = &command{
+	name:  "check",
+	usage: "check [flags]",
+	short: "Parse and render the project in memory without writing output",
+	run:   runCheck,
+}
+
+func runCheck(cmd *command, args []string) error {
+	var pf projectFlags
+	flags := newFlagSet(cmd)
+	pf.register(flags)
+	timeout := flags.Duration("timeout", 0, "abort the check if it takes longer than this, e.g. 2m")
+	flags.Parse(args)
+	pf.setupLogging()
+
+	p, err := pf.project(sgunk.WithDryRun(true))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := buildContext(*timeout)
+	defer cancel()
+	err = p.Generate(ctx)
+	pf.reportSkipped(p)
+	if err := pf.reportDiagnostics(p, err); err != nil {
+		return err
+	}
+	pf.verbosef("%s ok", pf.dir)
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+var cleanCommand = &command{
+	name:  "clean",
+	usage: "clean [flags]",
+	short: "Remove the build directory, leftover backups and the build cache",
+	run:   runClean,
+}
+
+func runClean(cmd *command, args []string) error {
+	var pf projectFlags
+	flags := newFlagSet(cmd)
+	pf.register(flags)
+	dryRun := flags.Bool("dry-run", false, "list the directories that would be removed")
+	flags.Parse(args)
+	pf.setupLogging()
+
+	p, err := pf.project()
+	if err != nil {
+		return err
+	}
+	if err := p.Configure(); err != nil {
+		return err
+	}
+
+	buildDir := p.BuildDir()
+	for _, dir := range []string{buildDir, buildDir + ".bk", buildDir + ".failed", p.CacheDir()} {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+		if *dryRun {
+			fmt.Printf("would remove %s\n", dir)
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
+		pf.verbosef("removed %s", dir)
+	}
+	return nil
+}
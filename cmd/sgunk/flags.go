@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/diag"
+	"github.com/connormckelvey/sgunk/extension/blog"
+	"github.com/connormckelvey/sgunk/extension/collection"
+)
+
+// projectFlags are shared by every command that operates on a project.
+type projectFlags struct {
+	dir      string
+	siteDir  string
+	themeDir string
+	buildDir string
+	jobs     int
+	drafts   bool
+	verbose  bool
+	quiet    bool
+}
+
+func newFlagSet(cmd *command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() {
+		w := flags.Output()
+		fmt.Fprintf(w, "Usage: sgunk %s\n\n%s\n\nFlags:\n", cmd.usage, cmd.short)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func (pf *projectFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&pf.dir, "C", ".", "project `dir`ectory")
+	flags.StringVar(&pf.siteDir, "site-dir", "", "override site.dir from the project config")
+	flags.StringVar(&pf.themeDir, "theme-dir", "", "override theme.dir from the project config")
+	flags.StringVar(&pf.buildDir, "build-dir", "", "override build.dir from the project config")
+	flags.IntVar(&pf.jobs, "j", 0, "number of pages to render in parallel (default: build.parallelism or the number of CPUs)")
+	flags.BoolVar(&pf.drafts, "drafts", false, "include drafts and content scheduled in the future")
+	flags.BoolVar(&pf.verbose, "v", false, "verbose output")
+	flags.BoolVar(&pf.quiet, "q", false, "only print errors")
+}
+
+func (pf *projectFlags) setupLogging() {
+	if pf.quiet {
+		log.SetOutput(io.Discard)
+	}
+}
+
+// verbosef logs only when -v is set.
+func (pf *projectFlags) verbosef(format string, args ...any) {
+	if pf.verbose && !pf.quiet {
+		log.Printf(format, args...)
+	}
+}
+
+// reportSkipped logs the content the last build left out on purpose. It is
+// called whether or not the build succeeded, since a left out draft may be
+// why it failed.
+func (pf *projectFlags) reportSkipped(p *sgunk.Project) {
+	for _, skipped := range p.Skipped() {
+		log.Printf("skipped %s: %s", skipped.Path, skipped.Reason)
+	}
+}
+
+// reportDiagnostics prints the problems the last build found to stderr,
+// grouped by file, leaving out warnings with -q. Since the errors are
+// already listed, a diag.List err is replaced by a short error.
+func (pf *projectFlags) reportDiagnostics(p *sgunk.Project, err error) error {
+	var diags []diag.Diagnostic
+	for _, d := range p.Diagnostics() {
+		if d.Severity == diag.Error || !pf.quiet {
+			diags = append(diags, d)
+		}
+	}
+	if len(diags) > 0 {
+		if err := diag.Report(os.Stderr, diags); err != nil {
+			return err
+		}
+	}
+	var list diag.List
+	if errors.As(err, &list) {
+		return errors.New("site has errors")
+	}
+	return err
+}
+
+func (pf *projectFlags) options() []sgunk.ProjectOption {
+	opts := []sgunk.ProjectOption{
+		sgunk.WithWorkDir(pf.dir),
+		sgunk.WithExtensions(&blog.Extension{}, &collection.Extension{}),
+	}
+	if pf.siteDir != "" {
+		opts = append(opts, sgunk.WithSiteDir(pf.siteDir))
+	}
+	if pf.themeDir != "" {
+		opts = append(opts, sgunk.WithThemeDir(pf.themeDir))
+	}
+	if pf.buildDir != "" {
+		opts = append(opts, sgunk.WithBuildDir(pf.buildDir))
+	}
+	if pf.drafts {
+		opts = append(opts, sgunk.WithDrafts(true))
+	}
+	if pf.jobs > 0 {
+		opts = append(opts, sgunk.WithParallelism(pf.jobs))
+	}
+	return opts
+}
+
+func (pf *projectFlags) project(opts ...sgunk.ProjectOption) (*sgunk.Project, error) {
+	if _, err := os.Stat(pf.dir); err != nil {
+		return nil, err
+	}
+	return sgunk.New(append(pf.options(), opts...)...), nil
+}
+
+// buildContext returns a context that is canceled on interrupt or, if
+// timeout is positive, once timeout has passed.
+func buildContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"text/template"
+
+	"github.com/connormckelvey/sgunk"
+)
+
+// skeleton holds the files of a new project. base is always copied, blog
+// only with -blog, and config holds one template per config file format.
+//
+//go:embed skeleton
+var skeleton embed.FS
+
+var configTemplates = template.Must(template.New("config").Funcs(template.FuncMap{
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}).ParseFS(skeleton, "skeleton/config/*.tmpl"))
+
+// skeletonNames maps skeleton files to names that cannot be embedded or
+// committed as is.
+var skeletonNames = map[string]string{
+	"gitignore": ".gitignore",
+}
+
+var initCommand = &command{
+	name:  "init",
+	usage: "init [flags] [dir]",
+	short: "Create a new project with a starter theme",
+	run:   runInit,
+}
+
+func runInit(cmd *command, args []string) error {
+	flags := newFlagSet(cmd)
+	format := flags.String("format", "yml", "config file `format`: yml, yaml or json")
+	name := flags.String("name", "", "project name (default: the directory name)")
+	withBlog := flags.Bool("blog", false, "add the blog extension with a sample post")
+	flags.Parse(args)
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		*name = filepath.Base(dir)
+	}
+
+	configName := "project." + *format
+	tmpl := configTemplates.Lookup("project." + *format + ".tmpl")
+	if *format == "yaml" {
+		tmpl = configTemplates.Lookup("project.yml.tmpl")
+	}
+	if tmpl == nil {
+		return fmt.Errorf("unknown config format '%s'", *format)
+	}
+
+	files := make(map[string][]byte)
+	var config bytes.Buffer
+	if err := tmpl.Execute(&config, struct {
+		Name string
+		Blog bool
+	}{*name, *withBlog}); err != nil {
+		return err
+	}
+	files[configName] = config.Bytes()
+
+	roots := []string{"skeleton/base"}
+	if *withBlog {
+		roots = append(roots, "skeleton/blog")
+	}
+	for _, root := range roots {
+		if err := readSkeleton(root, files); err != nil {
+			return err
+		}
+	}
+
+	// Refuse to touch an existing project rather than mixing the two.
+	for existing := range files {
+		if _, err := os.Stat(filepath.Join(dir, existing)); err == nil {
+			return fmt.Errorf("%s already exists", filepath.Join(dir, existing))
+		}
+	}
+	if p := sgunk.New(sgunk.WithWorkDir(dir)); p.Configure() == nil {
+		return fmt.Errorf("%s already contains a project", dir)
+	}
+
+	for name, b := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			return err
+		}
+	}
+
+	if *withBlog {
+		pf := projectFlags{dir: dir}
+		p, err := pf.project()
+		if err != nil {
+			return err
+		}
+		if err := p.Configure(); err != nil {
+			return err
+		}
+		if _, err := p.NewContent("post", []string{"Hello World"}); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("created %s\n", dir)
+	return nil
+}
+
+func readSkeleton(root string, files map[string][]byte) error {
+	return fs.WalkDir(skeleton, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := skeleton.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		rel := name[len(root)+1:]
+		if renamed, ok := skeletonNames[path.Base(rel)]; ok {
+			rel = path.Join(path.Dir(rel), renamed)
+		}
+		files[filepath.FromSlash(rel)] = b
+		return nil
+	})
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitAndBuild(t *testing.T) {
+	name := `My Site: v2 # "quoted" & <tagged>`
+	for _, format := range []string{"yml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "site")
+			require.NoError(t, runInit(initCommand, []string{"-format", format, "-name", name, "-blog", dir}))
+			require.NoError(t, runBuild(buildCommand, []string{"-C", dir}))
+
+			config, err := sgunk.LoadConfigFile(afero.NewBasePathFs(afero.NewOsFs(), dir))
+			require.NoError(t, err)
+			assert.Equal(t, name, config.Name)
+		})
+	}
+}
@@ -1,24 +1,70 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
-
-	"github.com/connormckelvey/sgunk"
-	"github.com/connormckelvey/sgunk/extension/blog"
 )
 
+type command struct {
+	name  string
+	usage string
+	short string
+	run   func(cmd *command, args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		buildCommand,
+		serveCommand,
+		initCommand,
+		newCommand,
+		cleanCommand,
+		checkCommand,
+		versionCommand,
+	}
+}
+
+func findCommand(name string) (*command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+func usage() {
+	w := flag.CommandLine.Output()
+	fmt.Fprintf(w, "Usage: sgunk <command> [flags] [args]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.short)
+	}
+	fmt.Fprintf(w, "\nRun 'sgunk <command> -h' for help on a command.\n")
+}
+
 func main() {
-	wd, err := os.Getwd()
-	if err != nil {
-		log.Fatal(err)
+	log.SetFlags(0)
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		// Running sgunk without a command builds the current directory.
+		args = []string{buildCommand.name}
+	}
+
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "sgunk: unknown command '%s'\n\n", args[0])
+		usage()
+		os.Exit(2)
 	}
-	p := sgunk.New(
-		sgunk.WithWorkDir(wd),
-		sgunk.WithExtensions(&blog.Extension{}),
-	)
 
-	if err := p.Generate(); err != nil {
-		log.Fatal(err)
+	if err := cmd.run(cmd, args[1:]); err != nil {
+		log.Fatalf("sgunk %s: %v", cmd.name, err)
 	}
 }
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var newCommand = &command{
+	name:  "new",
+	usage: "new [flags] <kind> [args]",
+	short: "Create new content in the site directory",
+	run:   runNew,
+}
+
+func runNew(cmd *command, args []string) error {
+	var pf projectFlags
+	flags := newFlagSet(cmd)
+	pf.register(flags)
+	flags.Parse(args)
+	pf.setupLogging()
+
+	p, err := pf.project()
+	if err != nil {
+		return err
+	}
+	if err := p.Configure(); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		kinds, err := p.ContentKinds()
+		if err != nil {
+			return err
+		}
+		w := flags.Output()
+		fmt.Fprintf(w, "\nKinds:\n")
+		for _, kind := range kinds {
+			fmt.Fprintf(w, "  %s %s\n", kind.Name, kind.Usage)
+		}
+		return errors.New("expected a content kind")
+	}
+
+	path, err := p.NewContent(args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/server"
+)
+
+var serveCommand = &command{
+	name:  "serve",
+	usage: "serve [flags]",
+	short: "Build the project, serve it and rebuild on changes",
+	run:   runServe,
+}
+
+func runServe(cmd *command, args []string) error {
+	var pf projectFlags
+	flags := newFlagSet(cmd)
+	pf.register(flags)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	interval := flags.Duration("interval", 500*time.Millisecond, "how often to poll for changes")
+	incremental := flags.Bool("incremental", true, "only re-render outputs whose inputs changed")
+	flags.Parse(args)
+	pf.setupLogging()
+
+	p, err := pf.project(sgunk.WithIncremental(*incremental))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s := server.New(p,
+		server.WithAddr(*addr),
+		server.WithInterval(*interval),
+	)
+	return s.ListenAndServe(ctx)
+}
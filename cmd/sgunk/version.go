@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// version is set at link time with -ldflags "-X main.version=...".
+var version = ""
+
+var versionCommand = &command{
+	name:  "version",
+	usage: "version",
+	short: "Print the sgunk version",
+	run:   runVersion,
+}
+
+func runVersion(cmd *command, args []string) error {
+	flags := newFlagSet(cmd)
+	flags.Parse(args)
+
+	v := version
+	if v == "" {
+		v = "(devel)"
+		if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+			v = info.Main.Version
+		}
+	}
+	fmt.Printf("sgunk %s\n", v)
+	return nil
+}
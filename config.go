@@ -20,28 +20,94 @@ type DirConfig interface {
 
 type SiteConfig struct {
 	Dir string `yaml:"dir"`
+	// ContentExts lists the file extensions rendered as pages. Every
+	// other file is copied to the build directory as is.
+	ContentExts []string `yaml:"contentExts"`
 }
 
 func (c *SiteConfig) GetDir() string {
 	return c.Dir
 }
 
-type ThemeConfig struct {
+// DataConfig locates the data files exposed to templates as data.
+type DataConfig struct {
 	Dir string `yaml:"dir"`
 }
 
+func (c *DataConfig) GetDir() string {
+	return c.Dir
+}
+
+type ThemeConfig struct {
+	// Name selects a theme registered with WithThemes, such as one
+	// embedded in a Go package. Dir and Layers still override its files.
+	Name string `yaml:"name"`
+	Dir  string `yaml:"dir"`
+	// Static is the directory inside the theme that is copied into the
+	// build root. It defaults to "static".
+	Static string `yaml:"static"`
+	// Layers are theme directories stacked under Dir, from highest to
+	// lowest precedence. Templates and static files missing from Dir are
+	// looked up in them.
+	Layers []string `yaml:"layers"`
+}
+
+func (c *ThemeConfig) GetStatic() string {
+	if c.Static == "" {
+		return defaultThemeStaticDir
+	}
+	return c.Static
+}
+
 func (c *ThemeConfig) GetDir() string {
 	return c.Dir
 }
 
 type BuildConfig struct {
 	Dir string `yaml:"dir"`
+	// Parallelism is the number of pages rendered concurrently. It
+	// defaults to the number of CPUs.
+	Parallelism int `yaml:"parallelism"`
+	// Drafts includes drafts and content scheduled in the future, for
+	// previewing it locally.
+	Drafts bool `yaml:"drafts"`
+	// Fingerprint publishes assets under names containing a hash of
+	// their content, e.g. style.3f9a1c2b.css.
+	Fingerprint bool `yaml:"fingerprint"`
+	// Manifest is where the JSON asset manifest is written in the build
+	// directory when fingerprinting. It defaults to asset-manifest.json.
+	Manifest string `yaml:"manifest"`
+}
+
+func (c *BuildConfig) GetManifest() string {
+	if c.Manifest == "" {
+		return defaultManifestFile
+	}
+	return c.Manifest
 }
 
 func (c *BuildConfig) GetDir() string {
 	return c.Dir
 }
 
+// SitemapConfig enables the sitemap.xml and robots.txt of the build. Both
+// list absolute URLs, so the project needs a baseURL.
+type SitemapConfig struct {
+	Robots RobotsConfig `yaml:"robots"`
+}
+
+type RobotsConfig struct {
+	// Disabled skips robots.txt, leaving only the sitemap.
+	Disabled bool               `yaml:"disabled"`
+	Rules    []RobotsRuleConfig `yaml:"rules"`
+}
+
+type RobotsRuleConfig struct {
+	UserAgent string   `yaml:"userAgent"`
+	Allow     []string `yaml:"allow"`
+	Disallow  []string `yaml:"disallow"`
+}
+
 type ExtensionConfig struct {
 	Name   string
 	Config map[string]any
@@ -65,12 +131,53 @@ func (ex *ExtensionConfig) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+func (ex ExtensionConfig) MarshalYAML() (any, error) {
+	config := make(map[string]any, len(ex.Config)+1)
+	for k, v := range ex.Config {
+		config[k] = v
+	}
+	config["extension"] = ex.Name
+	return config, nil
+}
+
+func (ex *ExtensionConfig) UnmarshalJSON(b []byte) error {
+	var config map[string]any
+	if err := json.Unmarshal(b, &config); err != nil {
+		return err
+	}
+	name, _ := config["extension"].(string)
+	delete(config, "extension")
+
+	ex.Name = name
+	ex.Config = config
+	return nil
+}
+
 type ProjectConfig struct {
-	Name  string            `yaml:"name"`
-	Site  SiteConfig        `yaml:"site"`
-	Theme ThemeConfig       `yaml:"theme"`
-	Build BuildConfig       `yaml:"build"`
-	Uses  []ExtensionConfig `yaml:"uses"`
+	Name string `yaml:"name"`
+	// BaseURL is the absolute URL the site is published under, used
+	// wherever outputs need absolute links, such as feeds.
+	BaseURL string            `yaml:"baseURL"`
+	Site    SiteConfig        `yaml:"site"`
+	Data    DataConfig        `yaml:"data"`
+	Theme   ThemeConfig       `yaml:"theme"`
+	Build   BuildConfig       `yaml:"build"`
+	Sitemap *SitemapConfig    `yaml:"sitemap"`
+	Uses    []ExtensionConfig `yaml:"uses"`
+}
+
+// Map returns the config as it would be read from a config file, keyed by
+// the names used there.
+func (c *ProjectConfig) Map() (map[string]any, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 var configFiles = map[string]func([]byte, any) error{
@@ -79,6 +186,10 @@ var configFiles = map[string]func([]byte, any) error{
 	"project.yaml": yaml.Unmarshal,
 }
 
+// ErrConfigNotFound is returned by LoadConfigFile when none of the config
+// files exist.
+var ErrConfigNotFound = errors.New("config file not found")
+
 func LoadConfigFile(projectFS afero.Fs) (*ProjectConfig, error) {
 	for name, unmarshal := range configFiles {
 		b, err := afero.ReadFile(projectFS, name)
@@ -95,5 +206,5 @@ func LoadConfigFile(projectFS afero.Fs) (*ProjectConfig, error) {
 		return &c, nil
 	}
 
-	return nil, errors.New("config file not found")
+	return nil, ErrConfigNotFound
 }
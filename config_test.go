@@ -0,0 +1,26 @@
+package sgunk_test
+
+import (
+	"testing"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFileJSON(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fsys, "project.json", []byte(`{
+  "name": "test",
+  "uses": [{"extension": "example.com/ext", "path": "blog"}]
+}`), 0644))
+
+	config, err := sgunk.LoadConfigFile(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, "test", config.Name)
+	assert.Equal(t, []sgunk.ExtensionConfig{{
+		Name:   "example.com/ext",
+		Config: map[string]any{"path": "blog"},
+	}}, config.Uses)
+}
@@ -0,0 +1,189 @@
+package sgunk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/adrg/frontmatter"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultArchetypeDir = "archetypes"
+
+// ContentKind is a kind of content that can be scaffolded into the site,
+// such as a page or a blog post.
+type ContentKind struct {
+	Name string
+	// Usage describes the arguments New expects, e.g. "<path> [title]".
+	Usage string
+	// New returns the content to create from the command line arguments.
+	New func(args []string, now time.Time) (*Content, error)
+}
+
+// Content is new content to write to the site directory.
+type Content struct {
+	// Path is relative to the site directory.
+	Path string
+	// FrontMatter is merged over the front matter of the kind's archetype,
+	// so values set here win.
+	FrontMatter map[string]any
+}
+
+// ContentExtension is implemented by extensions that provide their own
+// kinds of content.
+type ContentExtension interface {
+	Extension
+	ContentKinds(p *Project, c map[string]any) ([]ContentKind, error)
+}
+
+var pageKind = ContentKind{
+	Name:  "page",
+	Usage: "<path> [title]",
+	New: func(args []string, now time.Time) (*Content, error) {
+		if len(args) == 0 {
+			return nil, errors.New("missing page path")
+		}
+		path := args[0]
+		if filepath.Ext(path) == "" {
+			path += ".md"
+		}
+		var title string
+		if len(args) > 1 {
+			title = args[1]
+		}
+		return &Content{
+			Path: path,
+			FrontMatter: map[string]any{
+				"page": map[string]any{"title": title},
+			},
+		}, nil
+	},
+}
+
+// ArchetypeDir returns the directory holding the archetypes new content is
+// created from, one <kind>.md file per kind.
+func (p *Project) ArchetypeDir() string {
+	return filepath.Join(p.workDir, defaultArchetypeDir)
+}
+
+// ContentKinds returns the kinds of content that can be created in the
+// project: pages, and those of the extensions it uses. It is only valid
+// after Configure.
+func (p *Project) ContentKinds() ([]ContentKind, error) {
+	kinds := []ContentKind{pageKind}
+	for _, use := range p.config.Uses {
+		ext, ok := p.extensions[use.Name]
+		if !ok {
+			return nil, fmt.Errorf("no known extension '%s'", use.Name)
+		}
+		contentExt, ok := ext.(ContentExtension)
+		if !ok {
+			continue
+		}
+		extKinds, err := contentExt.ContentKinds(p, use.Config)
+		if err != nil {
+			return nil, err
+		}
+		kinds = append(kinds, extKinds...)
+	}
+	return kinds, nil
+}
+
+// NewContent creates content of the given kind in the site directory and
+// returns its path. The content starts from the kind's archetype if the
+// project has one. It is only valid after Configure.
+func (p *Project) NewContent(kind string, args []string) (string, error) {
+	kinds, err := p.ContentKinds()
+	if err != nil {
+		return "", err
+	}
+	var ck *ContentKind
+	for i := range kinds {
+		if kinds[i].Name == kind {
+			ck = &kinds[i]
+			break
+		}
+	}
+	if ck == nil {
+		return "", fmt.Errorf("unknown content kind '%s'", kind)
+	}
+
+	content, err := ck.New(args, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	// Paths come from the command line, so keep them inside the site.
+	if !filepath.IsLocal(content.Path) {
+		return "", fmt.Errorf("%s is not a path inside the site directory", content.Path)
+	}
+	path := filepath.Join(p.SiteDir(), content.Path)
+	if _, err := p.rootFS.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+
+	fm, body, err := p.readArchetype(kind)
+	if err != nil {
+		return "", err
+	}
+	mergeFrontMatter(fm, content.FrontMatter)
+
+	b, err := yaml.Marshal(fm)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	out.WriteString("---\n")
+	out.Write(b)
+	out.WriteString("---\n\n")
+	out.Write(body)
+
+	if err := p.rootFS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := afero.WriteFile(p.rootFS, path, out.Bytes(), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (p *Project) readArchetype(kind string) (map[string]any, []byte, error) {
+	fm := make(map[string]any)
+	source, err := afero.ReadFile(p.rootFS, filepath.Join(p.ArchetypeDir(), kind+".md"))
+	if os.IsNotExist(err) {
+		return fm, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	// yaml.v3 decodes nested maps as map[string]any, which
+	// mergeFrontMatter relies on.
+	yamlFormat := frontmatter.NewFormat("---", "---", yaml.Unmarshal)
+	body, err := frontmatter.Parse(bytes.NewReader(source), &fm, yamlFormat)
+	if err != nil {
+		return nil, nil, fmt.Errorf("archetype %s: %w", kind, err)
+	}
+	return fm, bytes.TrimLeft(body, "\n"), nil
+}
+
+// mergeFrontMatter sets src over dst, merging nested maps key by key.
+func mergeFrontMatter(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, ok := v.(map[string]any)
+		if !ok {
+			dst[k] = v
+			continue
+		}
+		dstMap, ok := dst[k].(map[string]any)
+		if !ok {
+			dstMap = make(map[string]any)
+			dst[k] = dstMap
+		}
+		mergeFrontMatter(dstMap, srcMap)
+	}
+}
@@ -0,0 +1,69 @@
+package sgunk_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/extension/blog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContent(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "project.yml"), []byte(`
+name: test
+uses:
+  - extension: github.com/connormckelvey/sgunk/extension/blog
+    path: blog
+`), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "archetypes"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "archetypes", "post.md"), []byte(`---
+post:
+  title: Untitled
+  tags: [go]
+page:
+  template: blog-post.html
+---
+
+Write here.
+`), 0644))
+
+	project := sgunk.New(
+		sgunk.WithWorkDir(dir),
+		sgunk.WithExtensions(&blog.Extension{}),
+	)
+	require.NoError(t, project.Configure())
+
+	path, err := project.NewContent("post", []string{"Hello,", "World!"})
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`site/blog/post\.\d+\.hello-world\.md$`), filepath.ToSlash(path))
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+page:
+    template: blog-post.html
+    title: Hello, World!
+post:
+    tags:
+        - go
+    title: Hello, World!
+---
+
+Write here.
+`, string(b))
+
+	_, err = project.NewContent("recipe", nil)
+	assert.Error(t, err)
+
+	for _, path := range []string{"../../outside", "/etc/outside", "docs/../../outside"} {
+		_, err = project.NewContent("page", []string{path})
+		assert.Error(t, err, path)
+	}
+	_, err = os.Stat(filepath.Join(dir, "outside.md"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,217 @@
+// Package diag collects the problems found while building a site, so a
+// build can report every broken file at once instead of stopping at the
+// first.
+package diag
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Severity int
+
+const (
+	Warning Severity = iota
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Diagnostic is a problem found in a file. Paths start with the root the
+// file was read from, e.g. site/index.md or theme/main.html. Line and
+// Column start at 1 and are zero when unknown.
+type Diagnostic struct {
+	Severity Severity
+	Path     string
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.pos(), d.Severity, d.Message)
+}
+
+func (d Diagnostic) pos() string {
+	var b strings.Builder
+	b.WriteString(d.Path)
+	if d.Line > 0 {
+		fmt.Fprintf(&b, ":%d", d.Line)
+		if d.Column > 0 {
+			fmt.Fprintf(&b, ":%d", d.Column)
+		}
+	}
+	return b.String()
+}
+
+// List is returned as an error by builds that found errors. It holds the
+// diagnostics of severity Error.
+type List []Diagnostic
+
+func (l List) Error() string {
+	lines := make([]string, len(l))
+	for i, d := range l {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Err returns the errors in diags as a List, or nil if there are none.
+func Err(diags []Diagnostic) error {
+	var errs List
+	for _, d := range diags {
+		if d.Severity == Error {
+			errs = append(errs, d)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// PosError is an error at a position in a file. Path is empty until the
+// file is known.
+type PosError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PosError) Error() string {
+	d := Diagnostic{Path: e.Path, Line: e.Line, Column: e.Column}
+	return d.pos() + ": " + e.Err.Error()
+}
+
+func (e *PosError) Unwrap() error {
+	return e.Err
+}
+
+// At returns err located in the file at path. The positions of errors
+// raised in the file are moved down by lines, e.g. the lines of the front
+// matter stripped before templating. Errors already located in another
+// file, such as a partial, are returned as is.
+func At(err error, path string, lines int) error {
+	var pe *PosError
+	if !errors.As(err, &pe) {
+		return &PosError{Path: path, Err: err}
+	}
+	if pe.Path != "" {
+		return err
+	}
+	pe.Path = path
+	if pe.Line > 0 {
+		pe.Line += lines
+	}
+	return err
+}
+
+var yamlLine = regexp.MustCompile(`\bline (\d+)\b`)
+
+// FrontMatter returns err, raised parsing the front matter at the top of a
+// file, at the line of the file its YAML error points to.
+func FrontMatter(err error) error {
+	m := yamlLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	// The front matter starts after its opening delimiter.
+	return &PosError{Line: line + 1, Err: err}
+}
+
+// Position returns the line and column of offset in src, both starting at 1.
+func Position(src []byte, offset int) (line, column int) {
+	if offset > len(src) {
+		offset = len(src)
+	}
+	before := src[:offset]
+	line = strings.Count(string(before), "\n") + 1
+	column = offset - strings.LastIndex(string(before), "\n")
+	return line, column
+}
+
+// Collector records diagnostics. It is safe for concurrent use.
+type Collector struct {
+	mu    sync.Mutex
+	diags []Diagnostic
+}
+
+func (c *Collector) Add(d Diagnostic) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.diags = append(c.diags, d)
+}
+
+// Error records err as an error in the file at path, at the position err
+// carries if it is a PosError.
+func (c *Collector) Error(path string, err error) {
+	c.Add(fromError(Error, path, err))
+}
+
+// Warn records a warning about the file at path.
+func (c *Collector) Warn(path string, format string, args ...any) {
+	c.Add(Diagnostic{
+		Severity: Warning,
+		Path:     path,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Diagnostics returns the diagnostics recorded so far, in order of path and
+// position. A problem found twice, e.g. by a page and by a feed embedding
+// it, is returned once.
+func (c *Collector) Diagnostics() []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	diags := append([]Diagnostic(nil), c.diags...)
+	Sort(diags)
+	return slices.Compact(diags)
+}
+
+// Err returns the errors recorded so far as a List, or nil if there are
+// none.
+func (c *Collector) Err() error {
+	return Err(c.Diagnostics())
+}
+
+func fromError(severity Severity, path string, err error) Diagnostic {
+	d := Diagnostic{Severity: severity, Path: path}
+	var pe *PosError
+	if errors.As(err, &pe) {
+		if pe.Path != "" {
+			d.Path = pe.Path
+		}
+		d.Line = pe.Line
+		d.Column = pe.Column
+		err = pe.Err
+	}
+	d.Message = err.Error()
+	return d
+}
+
+// Sort orders diags by path and position.
+func Sort(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
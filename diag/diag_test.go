@@ -0,0 +1,45 @@
+package diag
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorReport(t *testing.T) {
+	var c Collector
+	c.Error("site/b.md", &PosError{Line: 3, Column: 5, Err: errors.New("boom")})
+	c.Warn("site/a.txt", "no parser, skipping")
+	c.Error("site/b.md", FrontMatter(errors.New("yaml: line 1: did not find expected key")))
+	c.Error("site/b.md", &PosError{Line: 3, Column: 5, Err: errors.New("boom")})
+
+	diags := c.Diagnostics()
+	require.Len(t, diags, 3)
+	assert.Equal(t, "site/b.md:2: error: yaml: line 1: did not find expected key", diags[1].String())
+
+	var b strings.Builder
+	require.NoError(t, Report(&b, diags))
+	assert.Equal(t, `site/a.txt
+  -  warning  no parser, skipping
+
+site/b.md
+  2    error  yaml: line 1: did not find expected key
+  3:5  error  boom
+
+2 errors, 1 warning
+`, b.String())
+
+	var list List
+	require.ErrorAs(t, c.Err(), &list)
+	assert.Len(t, list, 2)
+}
+
+func TestPosition(t *testing.T) {
+	src := []byte("ab\ncd\n")
+	line, column := Position(src, 4)
+	assert.Equal(t, 2, line)
+	assert.Equal(t, 2, column)
+}
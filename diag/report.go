@@ -0,0 +1,49 @@
+package diag
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Report writes diags grouped by file, followed by a count of errors and
+// warnings. diags must be sorted by path.
+func Report(w io.Writer, diags []Diagnostic) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var errs, warnings int
+	for i, d := range diags {
+		if i == 0 || diags[i-1].Path != d.Path {
+			if i > 0 {
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintln(tw, d.Path)
+		}
+		pos := "-"
+		switch {
+		case d.Column > 0:
+			pos = fmt.Sprintf("%d:%d", d.Line, d.Column)
+		case d.Line > 0:
+			pos = fmt.Sprint(d.Line)
+		}
+		// Keep multi-line messages, such as YAML errors, on their row.
+		message := strings.Join(strings.Fields(d.Message), " ")
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", pos, d.Severity, message)
+		if d.Severity == Error {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	if len(diags) > 0 {
+		fmt.Fprintf(tw, "\n%s, %s\n", plural(errs, "error"), plural(warnings, "warning"))
+	}
+	return tw.Flush()
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,114 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Config is the configuration of the blog extension, read from its entry in
+// the project's uses block.
+type Config struct {
+	Path string `mapstructure:"path"`
+	// Permalink is the pattern post URLs are built from, relative to
+	// Path. It supports :year, :month, :day, :hour, :minute, :second and
+	// :slug, taken from the post date and slug. Patterns ending in a
+	// slash publish posts as directories, e.g. ":year/:month/:slug/".
+	Permalink string `mapstructure:"permalink"`
+	// Drafts includes draft and scheduled posts, for previews. It is also
+	// enabled by build.drafts in the project config.
+	Drafts bool `mapstructure:"drafts"`
+
+	Feed  *FeedConfig  `mapstructure:"feed"`
+	Index *IndexConfig `mapstructure:"index"`
+	Tags  *TagsConfig  `mapstructure:"tags"`
+}
+
+// IndexConfig enables the blog index, which lists posts newest first over
+// numbered pages: /blog/, /blog/page/2/ and so on.
+type IndexConfig struct {
+	// Template is the theme template rendered for each page. It receives
+	// posts and pagination props.
+	Template string `mapstructure:"template"`
+	Title    string `mapstructure:"title"`
+	PageSize int    `mapstructure:"pageSize"`
+}
+
+// TagsConfig enables the tag taxonomy: an overview of every tag at
+// /blog/tags/ and a listing of the posts of each tag at /blog/tags/<slug>/.
+type TagsConfig struct {
+	// Template is the theme template of the overview. It receives a tags
+	// prop listing every tag with its name, slug, url and count.
+	Template string `mapstructure:"template"`
+	// TagTemplate is the theme template of each tag's listing. It
+	// receives tag, posts and pagination props.
+	TagTemplate string `mapstructure:"tagTemplate"`
+	Title       string `mapstructure:"title"`
+	PageSize    int    `mapstructure:"pageSize"`
+}
+
+const (
+	FeedFormatRSS  = "rss"
+	FeedFormatAtom = "atom"
+	FeedFormatJSON = "json"
+)
+
+// FeedConfig enables the blog feeds. Feeds are only written when it is set.
+type FeedConfig struct {
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+	Author      string `mapstructure:"author"`
+	// Limit is the number of most recent posts in each feed.
+	Limit int `mapstructure:"limit"`
+	// Formats lists the feeds to write, out of rss, atom and json. All
+	// three are written by default.
+	Formats []string `mapstructure:"formats"`
+}
+
+const (
+	defaultFeedLimit = 20
+	defaultPageSize  = 10
+)
+
+func decodeConfig(c map[string]any) (*Config, error) {
+	var config Config
+	if err := mapstructure.Decode(c, &config); err != nil {
+		return nil, err
+	}
+	if config.Permalink == "" {
+		config.Permalink = defaultPermalink
+	}
+	if feed := config.Feed; feed != nil {
+		if feed.Limit <= 0 {
+			feed.Limit = defaultFeedLimit
+		}
+		if len(feed.Formats) == 0 {
+			feed.Formats = []string{FeedFormatRSS, FeedFormatAtom, FeedFormatJSON}
+		}
+		for _, format := range feed.Formats {
+			switch format {
+			case FeedFormatRSS, FeedFormatAtom, FeedFormatJSON:
+			default:
+				return nil, fmt.Errorf("unknown feed format '%s'", format)
+			}
+		}
+	}
+	if index := config.Index; index != nil {
+		if index.Template == "" {
+			return nil, errors.New("blog index requires a template")
+		}
+		if index.PageSize <= 0 {
+			index.PageSize = defaultPageSize
+		}
+	}
+	if tags := config.Tags; tags != nil {
+		if tags.Template == "" || tags.TagTemplate == "" {
+			return nil, errors.New("blog tags require a template and a tagTemplate")
+		}
+		if tags.PageSize <= 0 {
+			tags.PageSize = defaultPageSize
+		}
+	}
+	return &config, nil
+}
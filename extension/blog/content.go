@@ -0,0 +1,43 @@
+package blog
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/util"
+)
+
+// ContentKinds lets `sgunk new post "Title"` create posts named so that
+// BlogEntryParser reads their timestamp and slug back.
+func (be *Extension) ContentKinds(project *sgunk.Project, c map[string]any) ([]sgunk.ContentKind, error) {
+	config, err := decodeConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return []sgunk.ContentKind{{
+		Name:  "post",
+		Usage: "<title>",
+		New: func(args []string, now time.Time) (*sgunk.Content, error) {
+			title := strings.Join(args, " ")
+			slug := util.Slugify(title)
+			if slug == "" {
+				return nil, errors.New("post title has no letters or digits to build a slug from")
+			}
+			return &sgunk.Content{
+				Path: postFileName(config.Path, now, slug),
+				FrontMatter: map[string]any{
+					"post": map[string]any{"title": title},
+					"page": map[string]any{"title": title},
+				},
+			}, nil
+		},
+	}}, nil
+}
+
+func postFileName(root string, now time.Time, slug string) string {
+	return path.Join(root, fmt.Sprintf("post.%d.%s.md", now.UnixMilli(), slug))
+}
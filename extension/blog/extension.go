@@ -1,10 +1,11 @@
 package blog
 
 import (
+	"errors"
+
 	"github.com/connormckelvey/sgunk"
 	"github.com/connormckelvey/sgunk/parser"
 	"github.com/connormckelvey/sgunk/renderer"
-	"github.com/mitchellh/mapstructure"
 )
 
 const extName = "github.com/connormckelvey/sgunk/extension/blog"
@@ -17,24 +18,24 @@ func (be *Extension) Name() string {
 }
 
 func (be *Extension) Register(project *sgunk.Project, c map[string]any) error {
-	var config struct {
-		Path string `mapstructure:"path"`
-	}
-	err := mapstructure.Decode(c, &config)
+	config, err := decodeConfig(c)
 	if err != nil {
 		return err
 	}
+	projectConfig := project.Config()
+	config.Drafts = config.Drafts || projectConfig.Build.Drafts
+	if config.Feed != nil && projectConfig.BaseURL == "" {
+		return errors.New("blog feeds require baseURL in the project config")
+	}
 	useEntryParsers := parser.WithEntryParsers(
-		NewBlogEntryParser(config.Path),
+		NewBlogEntryParser(config),
 	)
 	if err := sgunk.WithParserOptions(useEntryParsers)(project); err != nil {
 		return err
 	}
 	useEntryRenderers := renderer.WithEntryRenderers(
-		&BlogRenderer{},
+		NewBlogRenderer(config, projectConfig.BaseURL, projectConfig.Name),
 	)
-	sgunk.WithRendererOptions(useEntryRenderers)(project)
-
 	if err := sgunk.WithRendererOptions(useEntryRenderers)(project); err != nil {
 		return err
 	}
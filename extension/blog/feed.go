@@ -0,0 +1,290 @@
+package blog
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"path"
+	"time"
+
+	"github.com/connormckelvey/sgunk/renderer"
+)
+
+type feed struct {
+	Title       string
+	Description string
+	Author      string
+	HomeURL     string
+	Updated     time.Time
+	Items       []*feedItem
+}
+
+type feedItem struct {
+	URL       string
+	Title     string
+	Content   string
+	Published time.Time
+	Tags      []string
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	AtomLink      atomLink   `xml:"atom:link"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+func (f *feed) rss(feedURL string) ([]byte, error) {
+	doc := rssDocument{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.HomeURL,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.Format(time.RFC1123Z),
+			AtomLink:      atomLink{Href: feedURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, item := range f.Items {
+		doc.Channel.Items = append(doc.Channel.Items, &rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.URL},
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Categories:  item.Tags,
+			Description: item.Content,
+		})
+	}
+	return marshalXML(doc)
+}
+
+type atomDocument struct {
+	XMLName xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string       `xml:"title"`
+	ID      string       `xml:"id"`
+	Updated string       `xml:"updated"`
+	Links   []atomLink   `xml:"link"`
+	Author  *atomPerson  `xml:"author,omitempty"`
+	Entries []*atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Links      []atomLink     `xml:"link"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+func (f *feed) atom(feedURL string) ([]byte, error) {
+	doc := atomDocument{
+		Title:   f.Title,
+		ID:      f.HomeURL,
+		Updated: f.Updated.Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: f.HomeURL},
+			{Href: feedURL, Rel: "self", Type: "application/atom+xml"},
+		},
+	}
+	if f.Author != "" {
+		doc.Author = &atomPerson{Name: f.Author}
+	}
+	for _, item := range f.Items {
+		entry := &atomEntry{
+			Title:     item.Title,
+			ID:        item.URL,
+			Updated:   item.Published.Format(time.RFC3339),
+			Published: item.Published.Format(time.RFC3339),
+			Links:     []atomLink{{Href: item.URL, Rel: "alternate", Type: "text/html"}},
+			Content:   atomContent{Type: "html", Body: item.Content},
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+type jsonFeed struct {
+	Version     string          `json:"version"`
+	Title       string          `json:"title"`
+	HomePageURL string          `json:"home_page_url"`
+	FeedURL     string          `json:"feed_url"`
+	Description string          `json:"description,omitempty"`
+	Authors     []jsonFeedActor `json:"authors,omitempty"`
+	Items       []*jsonFeedItem `json:"items"`
+}
+
+type jsonFeedActor struct {
+	Name string `json:"name"`
+}
+
+type jsonFeedItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentHTML   string   `json:"content_html"`
+	DatePublished string   `json:"date_published"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+func (f *feed) json(feedURL string) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.HomeURL,
+		FeedURL:     feedURL,
+		Description: f.Description,
+		Items:       []*jsonFeedItem{},
+	}
+	if f.Author != "" {
+		doc.Authors = []jsonFeedActor{{Name: f.Author}}
+	}
+	for _, item := range f.Items {
+		doc.Items = append(doc.Items, &jsonFeedItem{
+			ID:            item.URL,
+			URL:           item.URL,
+			Title:         item.Title,
+			ContentHTML:   item.Content,
+			DatePublished: item.Published.Format(time.RFC3339),
+			Tags:          item.Tags,
+		})
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var feedFiles = map[string]string{
+	FeedFormatRSS:  "feed.xml",
+	FeedFormatAtom: "atom.xml",
+	FeedFormatJSON: "feed.json",
+}
+
+func (r *BlogRenderer) newFeed(blog *BlogNode, posts []*BlogPostNode, context *renderer.RenderContext) (*feed, error) {
+	config := r.config.Feed
+	f := &feed{
+		Title:       config.Title,
+		Description: config.Description,
+		Author:      config.Author,
+		HomeURL:     r.url(blog.Root) + "/",
+	}
+	if f.Title == "" {
+		f.Title = r.name
+	}
+
+	if len(posts) > config.Limit {
+		posts = posts[:config.Limit]
+	}
+	for _, post := range posts {
+		attrs, err := postAttributes(post)
+		if err != nil {
+			return nil, err
+		}
+		content, err := context.RenderContent(post)
+		if err != nil {
+			return nil, err
+		}
+		f.Items = append(f.Items, &feedItem{
+			URL:       r.baseURL + attrs.URL,
+			Title:     attrs.Title,
+			Content:   string(content),
+			Published: post.CreatedAt,
+			Tags:      attrs.Tags,
+		})
+		if post.CreatedAt.After(f.Updated) {
+			f.Updated = post.CreatedAt
+		}
+	}
+	return f, nil
+}
+
+func (r *BlogRenderer) writeFeeds(blog *BlogNode, posts []*BlogPostNode, context *renderer.RenderContext) error {
+	if r.config.Feed == nil {
+		return nil
+	}
+	f, err := r.newFeed(blog, posts, context)
+	if err != nil {
+		return err
+	}
+	for _, format := range r.config.Feed.Formats {
+		name := feedFiles[format]
+		feedURL := r.url(blog.Root, name)
+
+		var b []byte
+		switch format {
+		case FeedFormatRSS:
+			b, err = f.rss(feedURL)
+		case FeedFormatAtom:
+			b, err = f.atom(feedURL)
+		case FeedFormatJSON:
+			b, err = f.json(feedURL)
+		}
+		if err != nil {
+			return err
+		}
+		if err := context.WriteFile(path.Join(blog.Root, name), b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
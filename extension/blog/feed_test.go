@@ -0,0 +1,81 @@
+package blog
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/sgunktest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const feedFixture = `
+-- project.yml --
+name: Example
+baseURL: https://example.com
+uses:
+  - extension: github.com/connormckelvey/sgunk/extension/blog
+    path: blog
+    feed:
+      title: Example Blog
+      limit: 2
+      formats: [rss, json]
+-- site/blog/post.1712000000000.oldest.md --
+---
+post:
+  title: Oldest
+---
+
+Oldest post.
+-- site/blog/post.1712859807912.newest.md --
+---
+post:
+  title: Newest
+  tags: [go]
+---
+
+**Newest** post.
+-- site/blog/post.1712702001240.middle.md --
+---
+post:
+  title: Middle
+---
+
+Middle post.
+`
+
+// Feeds list the most recent posts newest first, with absolute URLs and
+// their rendered content.
+func TestFeeds(t *testing.T) {
+	project := sgunktest.Build(t, sgunktest.ParseArchive([]byte(feedFixture)),
+		sgunk.WithExtensions(&Extension{}),
+	)
+	buildFS := project.BuildFS()
+
+	b, err := afero.ReadFile(buildFS, "blog/feed.json")
+	require.NoError(t, err)
+	var feed jsonFeed
+	require.NoError(t, json.Unmarshal(b, &feed))
+	assert.Equal(t, "Example Blog", feed.Title)
+	assert.Equal(t, "https://example.com/blog/", feed.HomePageURL)
+	assert.Equal(t, "https://example.com/blog/feed.json", feed.FeedURL)
+	require.Len(t, feed.Items, 2)
+	assert.Equal(t, "Newest", feed.Items[0].Title)
+	assert.Equal(t, "https://example.com/blog/2024/04/11/newest.html", feed.Items[0].URL)
+	assert.Equal(t, "2024-04-11T18:23:27Z", feed.Items[0].DatePublished)
+	assert.Equal(t, []string{"go"}, feed.Items[0].Tags)
+	assert.Contains(t, feed.Items[0].ContentHTML, "<strong>Newest</strong>")
+	assert.Equal(t, "Middle", feed.Items[1].Title)
+
+	b, err = afero.ReadFile(buildFS, "blog/feed.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `<rss version="2.0"`)
+	assert.Contains(t, string(b), "<link>https://example.com/blog/2024/04/11/newest.html</link>")
+	assert.Contains(t, string(b), "&lt;strong&gt;Newest&lt;/strong&gt;")
+	assert.NotContains(t, string(b), "Oldest")
+
+	_, err = buildFS.Stat("blog/atom.xml")
+	assert.Error(t, err)
+}
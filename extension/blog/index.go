@@ -0,0 +1,92 @@
+package blog
+
+import (
+	"path"
+	"strconv"
+
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/util"
+)
+
+// Pagination is passed to listing templates as the pagination prop.
+type Pagination struct {
+	Current  int    `mapstructure:"current"`
+	Total    int    `mapstructure:"total"`
+	PageSize int    `mapstructure:"pageSize"`
+	PrevURL  string `mapstructure:"prevURL"`
+	NextURL  string `mapstructure:"nextURL"`
+}
+
+// pageDir returns the directory of listing page n under root. The first
+// page is root itself.
+func pageDir(root string, n int) string {
+	if n == 1 {
+		return root
+	}
+	return path.Join(root, "page", strconv.Itoa(n))
+}
+
+// writeListing renders posts through template over as many pages of
+// pageSize as needed, under root. props are passed to every page.
+func (r *BlogRenderer) writeListing(blog *BlogNode, root string, template string, pageSize int, posts []*BlogPostNode, props map[string]any, context *renderer.RenderContext) error {
+	total := (len(posts) + pageSize - 1) / pageSize
+	if total == 0 {
+		total = 1
+	}
+
+	var err error
+	for n := 1; n <= total; n++ {
+		pagination := Pagination{
+			Current:  n,
+			Total:    total,
+			PageSize: pageSize,
+		}
+		if n > 1 {
+			pagination.PrevURL = r.path(pageDir(root, n-1)) + "/"
+		}
+		if n < total {
+			pagination.NextURL = r.path(pageDir(root, n+1)) + "/"
+		}
+
+		start := (n - 1) * pageSize
+		end := min(start+pageSize, len(posts))
+		var pagePosts []map[string]any
+		for _, post := range posts[start:end] {
+			pagePosts = append(pagePosts, postProps(post))
+		}
+
+		pageProps := make(map[string]any, len(props)+2)
+		for k, v := range props {
+			pageProps[k] = v
+		}
+		pageProps["posts"] = pagePosts
+		pageProps["pagination"], err = util.MarshalMap(pagination)
+		if err != nil {
+			return err
+		}
+
+		b, err := context.RenderTemplate(template, pageProps)
+		if err != nil {
+			return err
+		}
+		if err := context.WriteFile(path.Join(pageDir(root, n), "index.html"), b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *BlogRenderer) writeIndex(blog *BlogNode, posts []*BlogPostNode, context *renderer.RenderContext) error {
+	config := r.config.Index
+	if config == nil {
+		return nil
+	}
+	title := config.Title
+	if title == "" {
+		title = r.name
+	}
+	props := map[string]any{
+		"page": map[string]any{"title": title},
+	}
+	return r.writeListing(blog, blog.Root, config.Template, config.PageSize, posts, props, context)
+}
@@ -0,0 +1,60 @@
+package blog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/sgunktest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const indexFixture = `
+-- project.yml --
+name: Example
+uses:
+  - extension: github.com/connormckelvey/sgunk/extension/blog
+    path: blog
+    index:
+      title: Posts
+      template: index.html
+      pageSize: 2
+-- theme/index.html --
+<% page.title %>|<% posts.map(post => post.title).join(",") %>|<% pagination.current %>/<% pagination.total %>|<% pagination.prevURL %>|<% pagination.nextURL %>
+-- site/blog/post.1712000000000.oldest.md --
+---
+post:
+  title: Oldest
+---
+-- site/blog/post.1712859807912.newest.md --
+---
+post:
+  title: Newest
+---
+-- site/blog/post.1712702001240.middle.md --
+---
+post:
+  title: Middle
+---
+`
+
+// The index lists posts newest first over numbered pages linked to each
+// other.
+func TestIndex(t *testing.T) {
+	project := sgunktest.Build(t, sgunktest.ParseArchive([]byte(indexFixture)),
+		sgunk.WithExtensions(&Extension{}),
+	)
+	read := func(name string) string {
+		t.Helper()
+		b, err := afero.ReadFile(project.BuildFS(), name)
+		require.NoError(t, err)
+		return strings.TrimSpace(string(b))
+	}
+
+	assert.Equal(t, "Posts|Newest,Middle|1/2||/blog/page/2/", read("blog/index.html"))
+	assert.Equal(t, "Posts|Oldest|2/2|/blog/|", read("blog/page/2/index.html"))
+	_, err := project.BuildFS().Stat("blog/page/3/index.html")
+	assert.Error(t, err)
+}
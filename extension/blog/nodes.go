@@ -1,6 +1,8 @@
 package blog
 
 import (
+	"path"
+	"strings"
 	"time"
 
 	"github.com/connormckelvey/sgunk/tree"
@@ -11,6 +13,14 @@ const BlogKind = tree.NodeKind("blog")
 type BlogPostFrontMatter struct {
 	Title string   `yaml:"title"`
 	Tags  []string `yaml:"tags"`
+	// Date and Slug override the timestamp and slug of the file name.
+	Date string `yaml:"date"`
+	Slug string `yaml:"slug"`
+	// Draft, PublishAt and ExpireAt keep a post out of the build while it
+	// is a draft, before PublishAt and from ExpireAt on.
+	Draft     bool   `yaml:"draft"`
+	PublishAt string `yaml:"publishAt"`
+	ExpireAt  string `yaml:"expireAt"`
 }
 
 type BlogNode struct {
@@ -41,6 +51,11 @@ type BlogPostNode struct {
 	tree.BaseNode
 	Parts     tree.PageNameParts
 	CreatedAt time.Time
+	Slug      string
+	// Permalink is the path of the post relative to the blog root.
+	Permalink string
+	// Root is the blog root, relative to the site.
+	Root string
 }
 
 func NewBlogPostNode(path string, parts tree.PageNameParts, createdAt time.Time) *BlogPostNode {
@@ -48,9 +63,19 @@ func NewBlogPostNode(path string, parts tree.PageNameParts, createdAt time.Time)
 		BaseNode:  tree.NewBaseNode(path, false),
 		Parts:     parts,
 		CreatedAt: createdAt,
+		Slug:      parts.Slug,
 	}
 }
 
 func (*BlogPostNode) Kind() tree.NodeKind {
 	return BlogKind
 }
+
+// URL returns the root relative permalink of the post.
+func (n *BlogPostNode) URL() string {
+	url := "/" + path.Join(n.Root, n.Permalink)
+	if strings.HasSuffix(n.Permalink, "/") {
+		url += "/"
+	}
+	return url
+}
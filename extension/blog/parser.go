@@ -1,6 +1,7 @@
 package blog
 
 import (
+	"fmt"
 	"io/fs"
 	"path/filepath"
 	"strconv"
@@ -12,15 +13,53 @@ import (
 )
 
 type BlogEntryParser struct {
-	root string
+	root      string
+	permalink string
+	drafts    bool
+	now       func() time.Time
 }
 
-func NewBlogEntryParser(root string) *BlogEntryParser {
+func NewBlogEntryParser(config *Config) *BlogEntryParser {
 	return &BlogEntryParser{
-		root: root,
+		root:      config.Path,
+		permalink: config.Permalink,
+		drafts:    config.Drafts,
+		now:       time.Now,
 	}
 }
 
+// hiddenReason returns why a post is left out of the build, or an empty
+// string when it is published. Drafts and scheduled posts are shown when
+// drafts are enabled; expired posts never are.
+func (pp *BlogEntryParser) hiddenReason(fm *BlogPostFrontMatter) (string, error) {
+	now := pp.now()
+	if fm.ExpireAt != "" {
+		expireAt, err := parsePostDate(fm.ExpireAt)
+		if err != nil {
+			return "", err
+		}
+		if !now.Before(expireAt) {
+			return fmt.Sprintf("expired at %s", expireAt.Format(time.RFC3339)), nil
+		}
+	}
+	if pp.drafts {
+		return "", nil
+	}
+	if fm.Draft {
+		return "draft", nil
+	}
+	if fm.PublishAt != "" {
+		publishAt, err := parsePostDate(fm.PublishAt)
+		if err != nil {
+			return "", err
+		}
+		if now.Before(publishAt) {
+			return fmt.Sprintf("scheduled for %s", publishAt.Format(time.RFC3339)), nil
+		}
+	}
+	return "", nil
+}
+
 func (pp *BlogEntryParser) Test(path string, entry fs.FileInfo) (bool, error) {
 	hasPrefix := strings.HasPrefix(path, pp.root)
 	if entry.IsDir() {
@@ -51,6 +90,15 @@ func (pp *BlogEntryParser) Parse(path string, entry fs.FileInfo, context *parser
 		return nil, err
 	}
 
+	reason, err := pp.hiddenReason(&fm.Post)
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		context.Skip(path, reason)
+		return nil, nil
+	}
+
 	name := filepath.Base(path)
 	parts, _ := tree.GetEntryNameParts(name)
 
@@ -60,14 +108,28 @@ func (pp *BlogEntryParser) Parse(path string, entry fs.FileInfo, context *parser
 		if err != nil {
 			return nil, err
 		}
-		createdAt = time.UnixMilli(ms)
+		createdAt = time.UnixMilli(ms).UTC()
+	}
+	if fm.Post.Date != "" {
+		date, err := parsePostDate(fm.Post.Date)
+		if err != nil {
+			return nil, err
+		}
+		createdAt = date.UTC()
 	}
 
 	node := NewBlogPostNode(path, parts, createdAt)
-	err := node.AddAttrs("post", BlogPostAttributes{
+	if fm.Post.Slug != "" {
+		node.Slug = fm.Post.Slug
+	}
+	node.Permalink = expandPermalink(pp.permalink, createdAt, node.Slug)
+	node.Root = pp.root
+
+	err = node.AddAttrs("post", BlogPostAttributes{
 		Title:     fm.Post.Title,
 		Tags:      fm.Post.Tags,
 		CreatedAt: createdAt.Format(time.RFC3339),
+		URL:       node.URL(),
 	})
 	if err != nil {
 		return nil, err
@@ -79,4 +141,6 @@ type BlogPostAttributes struct {
 	Title     string   `mapstructure:"title"`
 	Tags      []string `mapstructure:"tags"`
 	CreatedAt string   `mapstructure:"createdAt"`
+	// URL is the root relative permalink of the post.
+	URL string `mapstructure:"url"`
 }
@@ -0,0 +1,73 @@
+package blog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/connormckelvey/sgunk/sgunktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHiddenReason(t *testing.T) {
+	now := time.Date(2024, time.April, 9, 12, 0, 0, 0, time.UTC)
+	pp := &BlogEntryParser{now: func() time.Time { return now }}
+
+	tests := []struct {
+		name   string
+		fm     BlogPostFrontMatter
+		drafts bool
+		reason string
+	}{
+		{name: "published", fm: BlogPostFrontMatter{PublishAt: "2024-04-01"}},
+		{name: "draft", fm: BlogPostFrontMatter{Draft: true}, reason: "draft"},
+		{name: "draft preview", fm: BlogPostFrontMatter{Draft: true}, drafts: true},
+		{name: "scheduled", fm: BlogPostFrontMatter{PublishAt: "2024-05-01"}, reason: "scheduled for 2024-05-01T00:00:00Z"},
+		{name: "scheduled preview", fm: BlogPostFrontMatter{PublishAt: "2024-05-01"}, drafts: true},
+		{name: "expired", fm: BlogPostFrontMatter{ExpireAt: "2024-04-01"}, drafts: true, reason: "expired at 2024-04-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pp.drafts = tt.drafts
+			reason, err := pp.hiddenReason(&tt.fm)
+			require.NoError(t, err)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+
+	pp.drafts = false
+	_, err := pp.hiddenReason(&BlogPostFrontMatter{PublishAt: "soon"})
+	assert.Error(t, err)
+}
+
+// Post dates are written in UTC whatever the zone of the build machine or
+// the front matter.
+func TestParseCreatedAtUTC(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("PDT", -7*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	fixture := sgunktest.ParseArchive([]byte(`
+-- site/blog/post.1712859807912.from-name.md --
+---
+post:
+  title: From name
+---
+-- site/blog/post.from-date.md --
+---
+post:
+  title: From date
+  date: 2024-04-11T11:23:27-07:00
+---
+`))
+	site := sgunktest.Parse(t, fixture, parser.WithEntryParsers(
+		NewBlogEntryParser(&Config{Path: "blog", Permalink: defaultPermalink}),
+	))
+	for _, path := range []string{"blog/post.1712859807912.from-name.md", "blog/post.from-date.md"} {
+		post := sgunktest.FindNode(t, site, path)
+		attrs, ok := post.GetAttrs("post")
+		require.True(t, ok)
+		assert.Equal(t, "2024-04-11T18:23:27Z", attrs["createdAt"], path)
+	}
+}
@@ -0,0 +1,45 @@
+package blog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultPermalink matches the layout posts have always been written with.
+const defaultPermalink = ":year/:month/:day/:slug.html"
+
+// expandPermalink fills in the placeholders of pattern for a post. Dates
+// are expanded in UTC so permalinks do not depend on the build machine.
+func expandPermalink(pattern string, date time.Time, slug string) string {
+	date = date.UTC()
+	r := strings.NewReplacer(
+		":year", fmt.Sprintf("%04d", date.Year()),
+		":month", fmt.Sprintf("%02d", int(date.Month())),
+		":day", fmt.Sprintf("%02d", date.Day()),
+		":hour", fmt.Sprintf("%02d", date.Hour()),
+		":minute", fmt.Sprintf("%02d", date.Minute()),
+		":second", fmt.Sprintf("%02d", date.Second()),
+		":slug", slug,
+	)
+	return strings.TrimPrefix(r.Replace(pattern), "/")
+}
+
+var postDateFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parsePostDate parses the date front matter field of a post. Dates without
+// a zone are taken as UTC.
+func parsePostDate(s string) (time.Time, error) {
+	for _, layout := range postDateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized post date '%s'", s)
+}
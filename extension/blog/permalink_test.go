@@ -0,0 +1,25 @@
+package blog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPermalink(t *testing.T) {
+	date := time.Date(2024, time.April, 9, 22, 33, 21, 0, time.UTC)
+
+	assert.Equal(t, "2024/04/09/hello.html", expandPermalink(defaultPermalink, date, "hello"))
+	assert.Equal(t, "2024/04/hello/", expandPermalink("/:year/:month/:slug/", date, "hello"))
+}
+
+func TestParsePostDate(t *testing.T) {
+	date, err := parsePostDate("2024-04-09")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.April, 9, 0, 0, 0, 0, time.UTC), date)
+
+	_, err = parsePostDate("April 9th")
+	assert.Error(t, err)
+}
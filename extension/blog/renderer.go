@@ -1,14 +1,30 @@
 package blog
 
 import (
+	"path"
 	"path/filepath"
-	"time"
+	"sort"
+	"strings"
 
 	"github.com/connormckelvey/sgunk/renderer"
 	"github.com/connormckelvey/sgunk/tree"
+	"github.com/connormckelvey/sgunk/util"
 )
 
 type BlogRenderer struct {
+	config  *Config
+	baseURL string
+	name    string
+}
+
+// NewBlogRenderer returns a renderer for blog nodes. baseURL and name come
+// from the project config and are used to build feeds.
+func NewBlogRenderer(config *Config, baseURL string, name string) *BlogRenderer {
+	return &BlogRenderer{
+		config:  config,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		name:    name,
+	}
 }
 
 func (r *BlogRenderer) Kind() tree.NodeKind {
@@ -28,12 +44,16 @@ func (*BlogRenderer) closeBlogNode(_ *BlogNode, context *renderer.RenderContext)
 	return nil
 }
 
+// postPath returns the output path of a post, relative to the blog root.
+func (f *BlogRenderer) postPath(node *BlogPostNode) string {
+	return filepath.FromSlash(renderer.PermalinkFile(node.Permalink))
+}
+
 func (f *BlogRenderer) openBlogPostNode(node *BlogPostNode, context *renderer.RenderContext) error {
-	datePath := time.Now().Format("2006/01/02")
-	if err := context.MkdirAll(datePath, 0755); err != nil {
+	postPath := f.postPath(node)
+	if err := context.MkdirAll(filepath.Dir(postPath), 0755); err != nil {
 		return err
 	}
-	postPath := filepath.Join(datePath, node.Parts.Slug+".html")
 	_, err := context.CreateFile(postPath)
 	if err != nil {
 		return err
@@ -72,3 +92,80 @@ func (r *BlogRenderer) Close(node tree.Node, context *renderer.RenderContext) er
 	}
 	return nil
 }
+
+// Finish writes the outputs that list every post of each blog in the site.
+func (r *BlogRenderer) Finish(site *tree.Site, context *renderer.RenderContext) error {
+	for _, blog := range findBlogs(site) {
+		posts := findPosts(blog)
+		if err := r.writeFeeds(blog, posts, context); err != nil {
+			return err
+		}
+		if err := r.writeIndex(blog, posts, context); err != nil {
+			return err
+		}
+		if err := r.writeTags(blog, posts, context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func findBlogs(root tree.Node) []*BlogNode {
+	var blogs []*BlogNode
+	for _, child := range root.Children() {
+		if blog, ok := child.(*BlogNode); ok {
+			blogs = append(blogs, blog)
+			continue
+		}
+		blogs = append(blogs, findBlogs(child)...)
+	}
+	return blogs
+}
+
+// findPosts returns every post under root, newest first.
+func findPosts(root tree.Node) []*BlogPostNode {
+	var posts []*BlogPostNode
+	var walk func(n tree.Node)
+	walk = func(n tree.Node) {
+		for _, child := range n.Children() {
+			if post, ok := child.(*BlogPostNode); ok {
+				posts = append(posts, post)
+			}
+			walk(child)
+		}
+	}
+	walk(root)
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+	return posts
+}
+
+func postAttributes(node *BlogPostNode) (*BlogPostAttributes, error) {
+	var attrs BlogPostAttributes
+	m, _ := node.GetAttrs("post")
+	if err := util.Unmarshal(m, &attrs); err != nil {
+		return nil, err
+	}
+	return &attrs, nil
+}
+
+// path returns the root relative URL of a path relative to the build root.
+func (r *BlogRenderer) path(parts ...string) string {
+	return "/" + path.Join(parts...)
+}
+
+// url returns the absolute URL of a path relative to the build root.
+func (r *BlogRenderer) url(parts ...string) string {
+	return r.baseURL + r.path(parts...)
+}
+
+// postProps returns the post attributes passed to listing templates.
+func postProps(post *BlogPostNode) map[string]any {
+	props, ok := post.GetAttrs("post")
+	if !ok {
+		return make(map[string]any)
+	}
+	return props
+}
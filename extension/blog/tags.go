@@ -0,0 +1,111 @@
+package blog
+
+import (
+	"path"
+	"sort"
+
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/util"
+)
+
+const tagsDir = "tags"
+
+// Tag is passed to taxonomy templates. Tags that slugify the same, such as
+// "ChatGPT" and "chatgpt", are one tag, named after its first spelling in
+// the newest post.
+type Tag struct {
+	Name  string `mapstructure:"name"`
+	Slug  string `mapstructure:"slug"`
+	URL   string `mapstructure:"url"`
+	Count int    `mapstructure:"count"`
+}
+
+// TagSlug normalizes a tag for use in URLs and for grouping.
+func TagSlug(tag string) string {
+	return util.Slugify(tag)
+}
+
+// collectTags groups posts, newest first, by tag slug. Tags are sorted by
+// slug.
+func (r *BlogRenderer) collectTags(blog *BlogNode, posts []*BlogPostNode) ([]*Tag, map[string][]*BlogPostNode, error) {
+	bySlug := make(map[string]*Tag)
+	tagPosts := make(map[string][]*BlogPostNode)
+	for _, post := range posts {
+		attrs, err := postAttributes(post)
+		if err != nil {
+			return nil, nil, err
+		}
+		seen := make(map[string]bool)
+		for _, name := range attrs.Tags {
+			slug := TagSlug(name)
+			if slug == "" || seen[slug] {
+				continue
+			}
+			seen[slug] = true
+
+			tag, ok := bySlug[slug]
+			if !ok {
+				tag = &Tag{
+					Name: name,
+					Slug: slug,
+					URL:  r.path(blog.Root, tagsDir, slug) + "/",
+				}
+				bySlug[slug] = tag
+			}
+			tag.Count++
+			tagPosts[slug] = append(tagPosts[slug], post)
+		}
+	}
+
+	tags := make([]*Tag, 0, len(bySlug))
+	for _, tag := range bySlug {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		return tags[i].Slug < tags[j].Slug
+	})
+	return tags, tagPosts, nil
+}
+
+func (r *BlogRenderer) writeTags(blog *BlogNode, posts []*BlogPostNode, context *renderer.RenderContext) error {
+	config := r.config.Tags
+	if config == nil {
+		return nil
+	}
+	tags, tagPosts, err := r.collectTags(blog, posts)
+	if err != nil {
+		return err
+	}
+
+	title := config.Title
+	if title == "" {
+		title = "Tags"
+	}
+
+	var tagProps []map[string]any
+	for _, tag := range tags {
+		props, err := util.MarshalMap(tag)
+		if err != nil {
+			return err
+		}
+		tagProps = append(tagProps, props)
+
+		listingProps := map[string]any{
+			"page": map[string]any{"title": tag.Name},
+			"tag":  props,
+		}
+		root := path.Join(blog.Root, tagsDir, tag.Slug)
+		if err := r.writeListing(blog, root, config.TagTemplate, config.PageSize, tagPosts[tag.Slug], listingProps, context); err != nil {
+			return err
+		}
+	}
+
+	b, err := context.RenderTemplate(config.Template, map[string]any{
+		"page": map[string]any{"title": title},
+		"tags": tagProps,
+	})
+	if err != nil {
+		return err
+	}
+	return context.WriteFile(path.Join(blog.Root, tagsDir, "index.html"), b)
+}
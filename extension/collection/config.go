@@ -0,0 +1,44 @@
+package collection
+
+import (
+	"errors"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Config is the configuration of a collection, read from its entry in the
+// project's uses block. Every entry generates the pages of one collection.
+type Config struct {
+	// Data is the dotted path of a list in the data object, e.g.
+	// "products" for data/products.json.
+	Data string `mapstructure:"data"`
+	// Template is the theme template each page is rendered with.
+	Template string `mapstructure:"template"`
+	// Permalink is the path of each page, relative to the build root.
+	// :field is replaced with the slug of the record's field, e.g.
+	// "products/:id/". Patterns ending in a slash publish pages as
+	// directories.
+	Permalink string `mapstructure:"permalink"`
+	// As is the name templates find the record under. It defaults to
+	// "record".
+	As string `mapstructure:"as"`
+	// Title is the field used as the title of each page. Records without
+	// it get an empty title.
+	Title string `mapstructure:"title"`
+}
+
+const defaultAs = "record"
+
+func decodeConfig(c map[string]any) (*Config, error) {
+	var config Config
+	if err := mapstructure.Decode(c, &config); err != nil {
+		return nil, err
+	}
+	if config.Data == "" || config.Template == "" || config.Permalink == "" {
+		return nil, errors.New("collections require data, template and permalink")
+	}
+	if config.As == "" {
+		config.As = defaultAs
+	}
+	return &config, nil
+}
@@ -0,0 +1,31 @@
+package collection
+
+import (
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/connormckelvey/sgunk/renderer"
+)
+
+const extName = "github.com/connormckelvey/sgunk/extension/collection"
+
+// Extension generates a page for every record of a list in the data
+// directory.
+type Extension struct {
+}
+
+func (ce *Extension) Name() string {
+	return extName
+}
+
+func (ce *Extension) Register(project *sgunk.Project, c map[string]any) error {
+	config, err := decodeConfig(c)
+	if err != nil {
+		return err
+	}
+	useGenerators := parser.WithGenerators(NewGenerator(config))
+	if err := sgunk.WithParserOptions(useGenerators)(project); err != nil {
+		return err
+	}
+	useEntryRenderers := renderer.WithEntryRenderers(&Renderer{})
+	return sgunk.WithRendererOptions(useEntryRenderers)(project)
+}
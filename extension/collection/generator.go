@@ -0,0 +1,137 @@
+package collection
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/connormckelvey/sgunk/tree"
+	"github.com/connormckelvey/sgunk/util"
+	"gopkg.in/yaml.v3"
+)
+
+// Generator appends a page for every record of a collection to the site.
+type Generator struct {
+	config *Config
+}
+
+func NewGenerator(config *Config) *Generator {
+	return &Generator{
+		config: config,
+	}
+}
+
+// Name identifies the collection by its permalink, since collections over
+// the same data differ in where their pages are published.
+func (g *Generator) Name() string {
+	return extName + ":" + g.config.Permalink
+}
+
+func (g *Generator) Generate(site *tree.Site, context *parser.ParserContext) error {
+	records, err := g.records(context.Data())
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int)
+	for i, record := range records {
+		permalink, err := expandPermalink(g.config.Permalink, record)
+		if err != nil {
+			return fmt.Errorf("collection %s, record %d: %w", g.config.Data, i, err)
+		}
+		if j, ok := seen[permalink]; ok {
+			return fmt.Errorf("collection %s: records %d and %d share the permalink %s", g.config.Data, j, i, permalink)
+		}
+		seen[permalink] = i
+
+		node, err := g.node(permalink, record)
+		if err != nil {
+			return err
+		}
+		site.AppendChild(node)
+	}
+	return nil
+}
+
+func (g *Generator) node(permalink string, record map[string]any) (*RecordNode, error) {
+	var title string
+	if v := record[g.config.Title]; g.config.Title != "" && v != nil {
+		title = fmt.Sprint(v)
+	}
+	var fm struct {
+		Page tree.PageFrontMatter `yaml:"page"`
+	}
+	fm.Page.Title = title
+	fm.Page.Template = g.config.Template
+	b, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, err
+	}
+	source := []byte("---\n" + string(b) + "---\n")
+
+	node := NewRecordNode(permalink, source)
+	if err := node.AddAttrs("page", parser.PageAttributes{
+		Title:    title,
+		Template: g.config.Template,
+		Sitemap:  true,
+	}); err != nil {
+		return nil, err
+	}
+	if err := node.AddAttrs(g.config.As, record); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// records looks up the collection in data. It must be a list of objects.
+func (g *Generator) records(data map[string]any) ([]map[string]any, error) {
+	var v any = data
+	for _, key := range strings.Split(g.config.Data, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("no data at %s", g.config.Data)
+		}
+		v, ok = m[key]
+		if !ok {
+			return nil, fmt.Errorf("no data at %s", g.config.Data)
+		}
+	}
+
+	switch v := v.(type) {
+	case []map[string]any:
+		return v, nil
+	case []any:
+		records := make([]map[string]any, 0, len(v))
+		for i, item := range v {
+			record, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("collection %s: record %d is not an object", g.config.Data, i)
+			}
+			records = append(records, record)
+		}
+		return records, nil
+	}
+	return nil, fmt.Errorf("collection %s is not a list", g.config.Data)
+}
+
+var permalinkField = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// expandPermalink replaces every :field of pattern with the slug of the
+// record's field.
+func expandPermalink(pattern string, record map[string]any) (string, error) {
+	var err error
+	permalink := permalinkField.ReplaceAllStringFunc(pattern, func(token string) string {
+		field := token[1:]
+		v, ok := record[field]
+		if !ok {
+			err = fmt.Errorf("missing field '%s' for permalink %s", field, pattern)
+			return ""
+		}
+		return util.Slugify(fmt.Sprint(v))
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(permalink, "/"), nil
+}
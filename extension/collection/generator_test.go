@@ -0,0 +1,83 @@
+package collection
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/connormckelvey/sgunk/diag"
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/connormckelvey/sgunk/sgunktest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPermalink(t *testing.T) {
+	permalink, err := expandPermalink("/products/:id/", map[string]any{"id": "Big Widget"})
+	require.NoError(t, err)
+	assert.Equal(t, "products/big-widget/", permalink)
+
+	_, err = expandPermalink("products/:sku.html", map[string]any{"id": 1})
+	assert.Error(t, err)
+}
+
+func TestGenerate(t *testing.T) {
+	data := map[string]any{"shop": map[string]any{"products": []any{
+		map[string]any{"id": "widget", "name": "Widget"},
+		map[string]any{"id": "gadget", "name": "Gadget"},
+		map[string]any{"id": "unnamed"},
+	}}}
+	config := &Config{
+		Data:      "shop.products",
+		Template:  "product.html",
+		Permalink: "products/:id.html",
+		As:        "product",
+		Title:     "name",
+	}
+	fixture := fstest.MapFS{"site/index.md": {Data: []byte("# Home\n")}}
+	site := sgunktest.Parse(t, fixture,
+		parser.WithData(data),
+		parser.WithGenerators(NewGenerator(config)),
+	)
+
+	require.Len(t, site.Children(), 4)
+	node := site.Children()[2].(*RecordNode)
+	assert.Equal(t, "/products/gadget.html", node.URL())
+	product, ok := node.GetAttrs("product")
+	require.True(t, ok)
+	assert.Equal(t, "Gadget", product["name"])
+	page, ok := node.GetAttrs("page")
+	require.True(t, ok)
+	assert.Equal(t, "product.html", page["template"])
+	assert.Equal(t, "Gadget", page["title"])
+
+	page, ok = site.Children()[3].GetAttrs("page")
+	require.True(t, ok)
+	assert.Equal(t, "", page["title"])
+
+	config.Permalink = "products.html"
+	_, err := parser.New(
+		parser.WithSiteFS(afero.NewBasePathFs(sgunktest.NewFS(t, fixture), "/site")),
+		parser.WithEntryParsers(&parser.DefaultParser{}),
+		parser.WithData(data),
+		parser.WithGenerators(NewGenerator(config)),
+	).Parse(context.Background())
+	var list diag.List
+	require.ErrorAs(t, err, &list)
+	assert.Contains(t, list[0].Message, "share the permalink products.html")
+}
+
+func TestGenerateSameData(t *testing.T) {
+	data := map[string]any{"products": []any{map[string]any{"id": "widget"}}}
+	pages := NewGenerator(&Config{Data: "products", Template: "product.html", Permalink: "products/:id.html", As: "product"})
+	feeds := NewGenerator(&Config{Data: "products", Template: "feed.xml", Permalink: "feeds/:id.xml", As: "product"})
+	site := sgunktest.Parse(t, fstest.MapFS{"site/index.md": {Data: []byte("# Home\n")}},
+		parser.WithData(data),
+		parser.WithGenerators(pages, feeds),
+	)
+	assert.Equal(t, []string{"index.md", "feeds/widget.xml", "products/widget.html"}, sgunktest.Paths(site))
+
+	_, err := parser.New(parser.WithGenerators(pages, pages)).Parse(context.Background())
+	assert.ErrorContains(t, err, "registered twice")
+}
@@ -0,0 +1,37 @@
+package collection
+
+import (
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/tree"
+)
+
+const CollectionKind = tree.NodeKind("collection")
+
+// RecordNode is a page generated from a record of a collection. It has no
+// file in the site; its source only names the theme template to render.
+type RecordNode struct {
+	tree.BaseNode
+	// Permalink is the path of the page relative to the build root.
+	Permalink string
+	source    []byte
+}
+
+func NewRecordNode(permalink string, source []byte) *RecordNode {
+	return &RecordNode{
+		BaseNode:  tree.NewBaseNode(renderer.PermalinkFile(permalink), false),
+		Permalink: permalink,
+		source:    source,
+	}
+}
+
+func (*RecordNode) Kind() tree.NodeKind {
+	return CollectionKind
+}
+
+func (n *RecordNode) Source() []byte {
+	return n.source
+}
+
+func (n *RecordNode) URL() string {
+	return "/" + n.Permalink
+}
@@ -0,0 +1,37 @@
+package collection
+
+import (
+	"path/filepath"
+
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/tree"
+)
+
+// Renderer writes record pages to their permalinks. The page itself is
+// rendered like any other, from the node's source and attributes.
+type Renderer struct {
+}
+
+func (r *Renderer) Kind() tree.NodeKind {
+	return CollectionKind
+}
+
+func (r *Renderer) Open(node tree.Node, context *renderer.RenderContext) error {
+	n, ok := node.(*RecordNode)
+	if !ok {
+		return nil
+	}
+	file := filepath.FromSlash(renderer.PermalinkFile(n.Permalink))
+	if err := context.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	_, err := context.CreateFile(file)
+	return err
+}
+
+func (r *Renderer) Close(node tree.Node, context *renderer.RenderContext) error {
+	if _, ok := node.(*RecordNode); !ok {
+		return nil
+	}
+	return context.PopFile().Close()
+}
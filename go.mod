@@ -4,15 +4,16 @@ go 1.22.1
 
 require (
 	dario.cat/mergo v1.0.0
+	github.com/BurntSushi/toml v1.3.2
 	github.com/adrg/frontmatter v0.2.0
+	github.com/dop251/goja v0.0.0-20240220182346-e401ed450204
 	github.com/mitchellh/mapstructure v1.5.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/tools v0.21.0
 )
 
 require (
-	github.com/BurntSushi/toml v1.3.2 // indirect
 	github.com/dlclark/regexp2 v1.11.0 // indirect
-	github.com/dop251/goja v0.0.0-20240220182346-e401ed450204 // indirect
 	github.com/go-sourcemap/sourcemap v2.1.4+incompatible // indirect
 	github.com/google/pprof v0.0.0-20240409012703-83162a5b38cd // indirect
 	golang.org/x/text v0.14.0 // indirect
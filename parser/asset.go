@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/connormckelvey/sgunk/tree"
+)
+
+// DefaultContentExts are the file extensions parsed as pages when the
+// project does not configure its own.
+var DefaultContentExts = []string{"md", "markdown", "html"}
+
+// AssetParser accepts every file whose extension is not a content
+// extension, so it is copied to the build directory instead of rendered.
+type AssetParser struct {
+	contentExts map[string]bool
+}
+
+func NewAssetParser(contentExts ...string) *AssetParser {
+	if len(contentExts) == 0 {
+		contentExts = DefaultContentExts
+	}
+	exts := make(map[string]bool)
+	for _, ext := range contentExts {
+		exts[normalizeExt(ext)] = true
+	}
+	return &AssetParser{
+		contentExts: exts,
+	}
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+func (ap *AssetParser) Test(path string, entry fs.FileInfo) (bool, error) {
+	if entry.IsDir() {
+		return false, nil
+	}
+	return !ap.contentExts[normalizeExt(filepath.Ext(path))], nil
+}
+
+func (ap *AssetParser) Parse(path string, entry fs.FileInfo, context *ParserContext) (tree.Node, error) {
+	return tree.NewAsset(path), nil
+}
@@ -2,16 +2,52 @@ package parser
 
 import (
 	"bytes"
+	"context"
 
 	"github.com/adrg/frontmatter"
+	"github.com/connormckelvey/sgunk/diag"
 	"github.com/spf13/afero"
 )
 
 type ParserContext struct {
+	ctx    context.Context
 	siteFS afero.Fs
 
 	// sources should belong to project so it can be shared with render
 	sources map[string][]byte
+	skipped []SkippedEntry
+	diags   *diag.Collector
+	data    map[string]any
+}
+
+// SkippedEntry is a source file an entry parser deliberately left out of
+// the site, such as a draft post.
+type SkippedEntry struct {
+	Path   string
+	Reason string
+}
+
+// Skip records that path was left out of the site and why. Entry parsers
+// call it before returning a nil node.
+func (pc *ParserContext) Skip(path string, reason string) {
+	pc.skipped = append(pc.skipped, SkippedEntry{Path: path, Reason: reason})
+}
+
+// Context returns the context of the Parse call. Entry parsers and
+// generators doing lengthy work should stop once it is done.
+func (pc *ParserContext) Context() context.Context {
+	return pc.ctx
+}
+
+// Data returns the data object of the build, as templates see it.
+func (pc *ParserContext) Data() map[string]any {
+	return pc.data
+}
+
+// Warn records a problem with path that does not stop it from being
+// parsed, reported once the build is done.
+func (pc *ParserContext) Warn(path string, format string, args ...any) {
+	pc.diags.Warn(sitePath(path), format, args...)
 }
 
 func (pc *ParserContext) Source(path string) ([]byte, error) {
@@ -33,7 +69,7 @@ func (pc *ParserContext) Content(path string) ([]byte, error) {
 	}
 	content, err := frontmatter.Parse(bytes.NewReader(source), &(map[string]any{}))
 	if err != nil {
-		return nil, err
+		return nil, diag.FrontMatter(err)
 	}
 	return content, nil
 }
@@ -44,7 +80,7 @@ func (pc *ParserContext) FrontMatter(path string, output any) error {
 		return err
 	}
 	if _, err := frontmatter.Parse(bytes.NewReader(source), output); err != nil {
-		return err
+		return diag.FrontMatter(err)
 	}
 	return nil
 }
@@ -36,4 +36,5 @@ type PageAttributes struct {
 	Meta     []*tree.PageMetaValue  `mapstructure:"meta"`
 	Links    []*tree.PageLinksValue `mapstructure:"links"`
 	Template string                 `mapstructure:"template"`
+	Sitemap  bool                   `mapstructure:"sitemap"`
 }
@@ -10,3 +10,11 @@ type EntryParser interface {
 	Test(path string, entry fs.FileInfo) (bool, error)
 	Parse(path string, entry fs.FileInfo, context *ParserContext) (tree.Node, error)
 }
+
+// Generator adds nodes that have no file in the site, such as pages built
+// from data, once the site directory has been parsed. Generator names must
+// be unique; registering two with the same name is an error.
+type Generator interface {
+	Name() string
+	Generate(site *tree.Site, context *ParserContext) error
+}
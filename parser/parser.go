@@ -1,9 +1,13 @@
 package parser
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io/fs"
 	"path/filepath"
+	"sort"
 
+	"github.com/connormckelvey/sgunk/diag"
 	"github.com/connormckelvey/sgunk/tree"
 	"github.com/spf13/afero"
 )
@@ -12,6 +16,12 @@ type Parser struct {
 	options []ParserOption
 	siteFS  afero.Fs
 	parsers []EntryParser
+	skipped []SkippedEntry
+	diags   []diag.Diagnostic
+	data    map[string]any
+
+	generators map[string]Generator
+	configured bool
 }
 
 type ParserOption interface {
@@ -38,62 +48,131 @@ func WithEntryParsers(parsers ...EntryParser) ParserOptionFunc {
 	}
 }
 
+// WithData passes the data object of the build to entry parsers and
+// generators through ParserContext.Data.
+func WithData(data map[string]any) ParserOptionFunc {
+	return func(p *Parser) error {
+		p.data = data
+		return nil
+	}
+}
+
+func WithGenerators(generators ...Generator) ParserOptionFunc {
+	return func(p *Parser) error {
+		if p.generators == nil {
+			p.generators = make(map[string]Generator)
+		}
+		for _, generator := range generators {
+			name := generator.Name()
+			if _, ok := p.generators[name]; ok {
+				return fmt.Errorf("generator %s is registered twice", name)
+			}
+			p.generators[name] = generator
+		}
+		return nil
+	}
+}
+
 func New(opts ...ParserOption) *Parser {
 	return &Parser{
 		options: opts,
 	}
 }
 
-func (p *Parser) Parse() (*tree.Site, error) {
-	for _, opt := range p.options {
-		err := opt.Apply(p)
-		if err != nil {
-			return nil, err
+// siteRoot prefixes the paths of diagnostics about site files.
+const siteRoot = "site"
+
+// Parse parses the site into a new tree on every call. The options passed
+// to New are applied by the first call. Parsing stops with ctx's error once
+// ctx is done.
+//
+// Entries that fail to parse are left out of the site and recorded in
+// Diagnostics. Parse then returns the rest of the site along with a
+// diag.List of the errors.
+func (p *Parser) Parse(ctx context.Context) (*tree.Site, error) {
+	if !p.configured {
+		for _, opt := range p.options {
+			err := opt.Apply(p)
+			if err != nil {
+				return nil, err
+			}
 		}
+		p.configured = true
 	}
 
 	site := &tree.Site{
 		BaseNode: tree.NewBaseNode("", true),
 	}
 	context := &ParserContext{
+		ctx:     ctx,
 		siteFS:  p.siteFS,
 		sources: make(map[string][]byte),
+		diags:   &diag.Collector{},
+		data:    p.data,
+	}
+	err := p.parse(".", site, context)
+	if err == nil {
+		err = p.generate(site, context)
 	}
-	if err := p.parse(".", site, context); err != nil {
+	p.skipped = context.skipped
+	p.diags = context.diags.Diagnostics()
+	if err != nil {
 		return nil, err
 	}
-	return site, nil
+	return site, diag.Err(p.diags)
+}
+
+// generate runs the generators in order of name, so generated nodes are
+// appended in the same order on every build.
+func (p *Parser) generate(site *tree.Site, context *ParserContext) error {
+	names := make([]string, 0, len(p.generators))
+	for name := range p.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := context.ctx.Err(); err != nil {
+			return err
+		}
+		if err := p.generators[name].Generate(site, context); err != nil {
+			context.diags.Error(name, err)
+		}
+	}
+	return nil
 }
 
+// Skipped returns the entries left out of the site by the last Parse.
+func (p *Parser) Skipped() []SkippedEntry {
+	return p.skipped
+}
+
+// Diagnostics returns the errors and warnings of the last Parse.
+func (p *Parser) Diagnostics() []diag.Diagnostic {
+	return p.diags
+}
+
+// parse appends the entries of dir to root. Entries that fail to parse are
+// recorded as errors and left out; only the root dir failing to read or
+// ctx being done stop the parse.
 func (p *Parser) parse(dir string, root tree.Node, context *ParserContext) error {
 	entries, err := afero.ReadDir(p.siteFS, dir)
 	if err != nil {
-		return err
+		if dir == "." {
+			return err
+		}
+		context.diags.Error(sitePath(dir), err)
+		return nil
 	}
 
 	for _, entry := range entries {
-		path := filepath.Join(dir, entry.Name())
-		// find parser
-		var parser EntryParser
-		for _, pp := range p.parsers {
-			ok, err := pp.Test(path, entry)
-			if err != nil {
-				return err
-			}
-			if ok {
-				parser = pp
-				break
-			}
-		}
-
-		if parser == nil {
-			log.Printf("no parser for '%s', skipping...", path)
-			continue
+		if err := context.ctx.Err(); err != nil {
+			return err
 		}
-
-		n, err := parser.Parse(path, entry, context)
+		path := filepath.Join(dir, entry.Name())
+		n, err := p.parseEntry(path, entry, context)
 		if err != nil {
-			return err
+			context.diags.Error(sitePath(path), err)
+			continue
 		}
 		if n == nil {
 			continue
@@ -105,25 +184,61 @@ func (p *Parser) parse(dir string, root tree.Node, context *ParserContext) error
 			if err := p.parse(path, n, context); err != nil {
 				return err
 			}
-			continue
 		}
+	}
+	return nil
+}
 
-		var fm struct {
-			Page tree.PageFrontMatter `yaml:"page"`
+// parseEntry returns the node for path, or nil if it is left out of the
+// site.
+func (p *Parser) parseEntry(path string, entry fs.FileInfo, context *ParserContext) (tree.Node, error) {
+	var parser EntryParser
+	for _, pp := range p.parsers {
+		ok, err := pp.Test(path, entry)
+		if err != nil {
+			return nil, err
 		}
-		if err := context.FrontMatter(path, &fm); err != nil {
-			return err
+		if ok {
+			parser = pp
+			break
 		}
+	}
 
-		err = n.AddAttrs("page", PageAttributes{
-			Title:    fm.Page.Title,
-			Meta:     fm.Page.Meta,
-			Links:    fm.Page.Links,
-			Template: fm.Page.Template,
-		})
-		if err != nil {
-			return err
-		}
+	if parser == nil {
+		context.Warn(path, "no parser, skipping")
+		return nil, nil
 	}
-	return nil
+
+	n, err := parser.Parse(path, entry, context)
+	if err != nil || n == nil || entry.IsDir() {
+		return n, err
+	}
+
+	// Assets are copied verbatim and have no front matter.
+	if n.Kind() == tree.AssetNodeKind {
+		return n, nil
+	}
+
+	var fm struct {
+		Page tree.PageFrontMatter `yaml:"page"`
+	}
+	if err := context.FrontMatter(path, &fm); err != nil {
+		return nil, err
+	}
+
+	err = n.AddAttrs("page", PageAttributes{
+		Title:    fm.Page.Title,
+		Meta:     fm.Page.Meta,
+		Links:    fm.Page.Links,
+		Template: fm.Page.Template,
+		Sitemap:  fm.Page.Sitemap == nil || *fm.Page.Sitemap,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func sitePath(path string) string {
+	return siteRoot + "/" + filepath.ToSlash(path)
 }
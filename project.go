@@ -1,13 +1,24 @@
 package sgunk
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 
+	"github.com/connormckelvey/sgunk/diag"
 	"github.com/connormckelvey/sgunk/parser"
 	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/sitemap"
+	"github.com/connormckelvey/sgunk/tree"
 	"github.com/spf13/afero"
 )
 
@@ -15,9 +26,35 @@ type Project struct {
 	config     *ProjectConfig
 	workDir    string
 	options    []ProjectOption
-	parser     *parser.Parser
-	renderer   *renderer.Renderer
 	extensions map[string]Extension
+
+	// configured is set once the options are applied, configSum is the
+	// sum of the config files they were applied with.
+	configured bool
+	configSum  string
+	// registered is set once the extensions the config uses have added
+	// their parser and renderer options.
+	registered      bool
+	parserOptions   []parser.ParserOption
+	rendererOptions []renderer.RendererOption
+
+	// parser and renderer are created by every build.
+	parser   *parser.Parser
+	renderer *renderer.Renderer
+
+	// overrides are set by options such as WithSiteDir. They are kept apart
+	// from the config and applied over it every time it is loaded, so the
+	// config passed to WithConfig is left as it is.
+	overrides configOverrides
+
+	themes      map[string]Theme
+	themeBase   fs.FS
+	dryRun      bool
+	incremental bool
+	rootFS      afero.Fs
+	outputFS    afero.Fs
+	buildFS     afero.Fs
+	site        *tree.Site
 }
 
 type ProjectOption interface {
@@ -39,11 +76,34 @@ func WithExtensions(extensions ...Extension) ProjectOptionFunc {
 	}
 }
 
+// WithRootFS reads the project from fsys instead of the operating system's
+// file system. It must come before WithWorkDir, which is resolved in fsys.
+func WithRootFS(fsys afero.Fs) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.rootFS = fsys
+		return nil
+	}
+}
+
+// WithOutputFS writes the build directory and the cache dir to fsys
+// instead of the root file system, at the same paths.
+func WithOutputFS(fsys afero.Fs) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.outputFS = fsys
+		return nil
+	}
+}
+
 func WithWorkDir(dir string) ProjectOptionFunc {
 	return func(p *Project) error {
+		// BasePathFs does not accept relative bases such as ".".
+		dir, err := p.absDir(dir)
+		if err != nil {
+			return err
+		}
 		p.workDir = dir
 
-		config, err := LoadConfigFile(afero.NewBasePathFs(afero.NewOsFs(), dir))
+		config, err := LoadConfigFile(afero.NewBasePathFs(p.rootFS, dir))
 		if err != nil {
 			return err
 		}
@@ -59,24 +119,73 @@ func WithConfig(config *ProjectConfig) ProjectOptionFunc {
 	}
 }
 
+func WithSiteDir(dir string) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.overrides.siteDir = dir
+		return nil
+	}
+}
+
+func WithThemeDir(dir string) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.overrides.themeDir = dir
+		return nil
+	}
+}
+
+func WithBuildDir(dir string) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.overrides.buildDir = dir
+		return nil
+	}
+}
+
+func WithParallelism(n int) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.overrides.parallelism = &n
+		return nil
+	}
+}
+
+// WithDrafts includes content that would otherwise be hidden from the
+// build, such as drafts and posts scheduled in the future, for previews.
+func WithDrafts(drafts bool) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.overrides.drafts = &drafts
+		return nil
+	}
+}
+
+// WithDryRun renders the project into memory instead of the build
+// directory, leaving any previous build untouched.
+func WithDryRun(dryRun bool) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.dryRun = dryRun
+		return nil
+	}
+}
+
+// WithIncremental reuses outputs from the previous build whose inputs have
+// not changed, based on the dependency graph persisted in the cache dir.
+func WithIncremental(incremental bool) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.incremental = incremental
+		return nil
+	}
+}
+
+// WithParserOptions adds options to the parser created by every build.
 func WithParserOptions(opts ...parser.ParserOption) ProjectOptionFunc {
 	return func(p *Project) error {
-		for _, opt := range opts {
-			if err := opt.Apply(p.parser); err != nil {
-				return err
-			}
-		}
+		p.parserOptions = append(p.parserOptions, opts...)
 		return nil
 	}
 }
 
+// WithRendererOptions adds options to the renderer created by every build.
 func WithRendererOptions(opts ...renderer.RendererOption) ProjectOptionFunc {
 	return func(p *Project) error {
-		for _, opt := range opts {
-			if err := opt.Apply(p.renderer); err != nil {
-				return err
-			}
-		}
+		p.rendererOptions = append(p.rendererOptions, opts...)
 		return nil
 	}
 }
@@ -85,16 +194,39 @@ func New(opts ...ProjectOption) *Project {
 
 	return &Project{
 		options:    opts,
-		parser:     parser.New(),
-		renderer:   renderer.New(),
 		extensions: make(map[string]Extension),
+		themes:     make(map[string]Theme),
+		rootFS:     afero.NewOsFs(),
 	}
 }
 
+// absDir resolves dir against the current working directory on the
+// operating system's file system, and against the root of any other.
+func (p *Project) absDir(dir string) (string, error) {
+	if _, ok := p.rootFS.(*afero.OsFs); ok {
+		return filepath.Abs(dir)
+	}
+	return filepath.Join(string(filepath.Separator), dir), nil
+}
+
+func (p *Project) outFS() afero.Fs {
+	if p.outputFS != nil {
+		return p.outputFS
+	}
+	return p.rootFS
+}
+
 const (
 	defaultSiteDir  = "site"
+	defaultDataDir  = "data"
 	defaultThemeDir = "theme"
 	defaultBuildDir = "_build"
+
+	defaultThemeStaticDir = "static"
+	defaultManifestFile   = "asset-manifest.json"
+
+	cacheDir     = ".sgunk"
+	depGraphFile = "deps.json"
 )
 
 func (p *Project) getConfigDir(c DirConfig, defaultDir string) (string, afero.Fs) {
@@ -102,86 +234,424 @@ func (p *Project) getConfigDir(c DirConfig, defaultDir string) (string, afero.Fs
 	if d := c.GetDir(); d != "" {
 		dir = d
 	}
-	fsys := afero.NewBasePathFs(afero.NewOsFs(), filepath.Join(p.workDir, dir))
+	fsys := afero.NewBasePathFs(p.rootFS, filepath.Join(p.workDir, dir))
 	return dir, fsys
 }
 
-func (p *Project) Generate() error {
-	var success bool
+// Config returns the project config. It is only valid after Configure.
+func (p *Project) Config() *ProjectConfig {
+	return p.config
+}
+
+// SiteDir returns the site directory, relative to the current working
+// directory. It is only valid after Configure.
+func (p *Project) SiteDir() string {
+	dir, _ := p.getConfigDir(&p.config.Site, defaultSiteDir)
+	return filepath.Join(p.workDir, dir)
+}
+
+// DataDir returns the directory of the data files, relative to the current
+// working directory. It is only valid after Configure.
+func (p *Project) DataDir() string {
+	dir, _ := p.getConfigDir(&p.config.Data, defaultDataDir)
+	return filepath.Join(p.workDir, dir)
+}
+
+// DataFS returns the data directory in the root file system. It is only
+// valid after Configure.
+func (p *Project) DataFS() afero.Fs {
+	_, fsys := p.getConfigDir(&p.config.Data, defaultDataDir)
+	return fsys
+}
+
+// ThemeDir returns the theme directory, relative to the current working
+// directory. It is only valid after Configure.
+func (p *Project) ThemeDir() string {
+	dir, _ := p.getConfigDir(&p.config.Theme, defaultThemeDir)
+	return filepath.Join(p.workDir, dir)
+}
+
+// BuildDir returns the build directory, relative to the current working
+// directory. It is only valid after Configure.
+func (p *Project) BuildDir() string {
+	dir, _ := p.getConfigDir(&p.config.Build, defaultBuildDir)
+	return filepath.Join(p.workDir, dir)
+}
+
+// CacheDir returns the directory holding state persisted between builds,
+// relative to the current working directory.
+func (p *Project) CacheDir() string {
+	return filepath.Join(p.workDir, cacheDir)
+}
 
+// ConfigFiles returns the paths of the config files that may define the
+// project, whether or not they exist.
+func (p *Project) ConfigFiles() []string {
+	var files []string
+	for name := range configFiles {
+		files = append(files, filepath.Join(p.workDir, name))
+	}
+	return files
+}
+
+// RootFS returns the file system the project is read from. The paths
+// returned by SiteDir, ThemeDirs, ConfigFiles and the like are paths in it.
+func (p *Project) RootFS() afero.Fs {
+	return p.rootFS
+}
+
+// BuildFS returns the filesystem written by the last call to Generate.
+func (p *Project) BuildFS() afero.Fs {
+	return p.buildFS
+}
+
+// Configure applies the project options, loading the project config. The
+// options are applied once; later calls apply them again only if the config
+// files have changed, so long running processes pick up edits.
+func (p *Project) Configure() error {
+	if p.configured {
+		sum, err := p.sumConfigFiles()
+		if err != nil {
+			return err
+		}
+		if sum == p.configSum {
+			return nil
+		}
+	}
+
+	// Start over from what the options set, so nothing is added twice.
+	p.config = nil
+	p.overrides = configOverrides{}
+	p.configured = false
+	p.registered = false
+	p.parserOptions = nil
+	p.rendererOptions = nil
 	for _, opt := range p.options {
 		if err := opt.Apply(p); err != nil {
 			return err
 		}
 	}
+	if p.config == nil {
+		return errors.New("no project config")
+	}
+	config := *p.config
+	p.overrides.apply(&config)
+	p.config = &config
+	sum, err := p.sumConfigFiles()
+	if err != nil {
+		return err
+	}
+	p.configSum = sum
+	p.configured = true
+	return nil
+}
+
+// configOverrides are the parts of the config set by options. Empty values
+// leave the config as it is.
+type configOverrides struct {
+	siteDir     string
+	themeDir    string
+	buildDir    string
+	parallelism *int
+	drafts      *bool
+}
+
+func (o configOverrides) apply(config *ProjectConfig) {
+	if o.siteDir != "" {
+		config.Site.Dir = o.siteDir
+	}
+	if o.themeDir != "" {
+		config.Theme.Dir = o.themeDir
+	}
+	if o.buildDir != "" {
+		config.Build.Dir = o.buildDir
+	}
+	if o.parallelism != nil {
+		config.Build.Parallelism = *o.parallelism
+	}
+	if o.drafts != nil {
+		config.Build.Drafts = *o.drafts
+	}
+}
+
+// sumConfigFiles returns a sum of the contents of the config files.
+func (p *Project) sumConfigFiles() (string, error) {
+	files := p.ConfigFiles()
+	sort.Strings(files)
+	h := sha256.New()
+	for _, name := range files {
+		b, err := afero.ReadFile(p.rootFS, name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", name, len(b))
+		h.Write(b)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// register lets the extensions the config uses add their parser and
+// renderer options. It runs once per configuration, so builds do not add
+// them again.
+func (p *Project) register() error {
+	if p.registered {
+		return nil
+	}
+	parserOptions, rendererOptions := len(p.parserOptions), len(p.rendererOptions)
+	for _, use := range p.config.Uses {
+		ext, ok := p.extensions[use.Name]
+		if !ok {
+			return fmt.Errorf("no known extension '%s'", use.Name)
+		}
+		if err := ext.Register(p, use.Config); err != nil {
+			// Drop what was registered so the next attempt starts over.
+			p.parserOptions = p.parserOptions[:parserOptions]
+			p.rendererOptions = p.rendererOptions[:rendererOptions]
+			return err
+		}
+	}
+	p.registered = true
+	return nil
+}
+
+// Generate builds the project. It can be called repeatedly on the same
+// project; every build gets a parser and renderer of its own. Once ctx is
+// done the build stops with ctx's error, leaving the previous build in
+// place.
+func (p *Project) Generate(ctx context.Context) error {
+	if err := p.Configure(); err != nil {
+		return err
+	}
+	if err := p.register(); err != nil {
+		return err
+	}
 
 	_, siteFS := p.getConfigDir(&p.config.Site, defaultSiteDir)
-	_, themeFS := p.getConfigDir(&p.config.Theme, defaultThemeDir)
-	buildDir, buildFS := p.getConfigDir(&p.config.Build, defaultBuildDir)
-	err := os.Rename(buildDir, buildDir+".bk")
+	themeFS, err := p.themeFS()
+	if err != nil {
+		return err
+	}
+	if p.dryRun {
+		p.buildFS = afero.NewMemMapFs()
+		return p.generate(ctx, siteFS, themeFS, p.buildFS)
+	}
+	outFS := p.outFS()
+	buildDir := p.BuildDir()
+	buildFS := afero.NewBasePathFs(outFS, buildDir)
+	p.buildFS = buildFS
+
+	var success bool
+	err = outFS.Rename(buildDir, buildDir+".bk")
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 
 	defer func() {
 		if !success {
-			if err := os.Rename(buildDir, buildDir+".failed"); err != nil {
+			if err := outFS.RemoveAll(buildDir + ".failed"); err != nil {
+				log.Println(err)
+			}
+			if err := outFS.Rename(buildDir, buildDir+".failed"); err != nil {
 				log.Println(err)
 			}
-			if err := os.Rename(buildDir+".bk", buildDir); err != nil {
+			if err := outFS.Rename(buildDir+".bk", buildDir); err != nil && !os.IsNotExist(err) {
 				log.Println(err)
 			}
 		} else {
-			if err := os.RemoveAll(buildDir + ".bk"); err != nil {
+			if err := outFS.RemoveAll(buildDir + ".bk"); err != nil {
 				log.Println(err)
 			}
 		}
 	}()
 
-	if err := os.MkdirAll(buildDir, 0755); err != nil {
+	if err := outFS.MkdirAll(buildDir, 0755); err != nil {
 		return err
 	}
 
-	if err := parser.WithSiteFS(siteFS)(p.parser); err != nil {
+	// The graph is recorded by every build, so it always describes the
+	// build directory an incremental build starts from.
+	fingerprint, err := p.fingerprint()
+	if err != nil {
 		return err
 	}
+	var opts []renderer.RendererOption
+	if p.incremental {
+		previousFS := afero.NewBasePathFs(outFS, buildDir+".bk")
+		opts = append(opts, p.previousBuild(fingerprint, previousFS))
+	}
 
-	if err := renderer.WithFS(siteFS, themeFS, buildFS)(p.renderer); err != nil {
+	if err := p.generate(ctx, siteFS, themeFS, buildFS, opts...); err != nil {
 		return err
 	}
 
-	for _, use := range p.config.Uses {
-		ext, ok := p.extensions[use.Name]
-		if !ok {
-			return fmt.Errorf("no known extension '%s'", use.Name)
-		}
-		err := ext.Register(p, use.Config)
-		if err != nil {
-			return err
-		}
+	graph := p.renderer.DepGraph()
+	graph.Fingerprint = fingerprint
+	if err := graph.Write(outFS, p.depGraphPath()); err != nil {
+		return err
 	}
 
-	if err := parser.WithEntryParsers(
-		&parser.DefaultParser{},
-	)(p.parser); err != nil {
-		return err
+	success = true
+	return nil
+}
+
+func (p *Project) depGraphPath() string {
+	return filepath.Join(p.CacheDir(), depGraphFile)
+}
+
+// fingerprint identifies the project configuration. Any change to it
+// invalidates every recorded dependency.
+func (p *Project) fingerprint() (string, error) {
+	var names []string
+	for name := range p.extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b, err := json.Marshal(struct {
+		Config     *ProjectConfig
+		Extensions []string
+	}{p.config, names})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (p *Project) previousBuild(fingerprint string, previousFS afero.Fs) renderer.RendererOption {
+	graph, err := renderer.ReadDepGraph(p.outFS(), p.depGraphPath())
+	switch {
+	case os.IsNotExist(err):
+		graph = nil
+	case err != nil:
+		log.Printf("ignoring unreadable dependency graph: %v", err)
+		graph = nil
+	case graph.Fingerprint != fingerprint:
+		graph = nil
+	}
+	return renderer.WithPreviousBuild(graph, previousFS)
+}
+
+// Site returns the site parsed by the last call to Generate.
+func (p *Project) Site() *tree.Site {
+	return p.site
+}
+
+// Skipped returns the source files the last Generate deliberately left out
+// of the site, such as draft posts.
+func (p *Project) Skipped() []parser.SkippedEntry {
+	if p.parser == nil {
+		return nil
+	}
+	return p.parser.Skipped()
+}
+
+// Diagnostics returns the errors and warnings of the last Generate, in
+// order of path and position.
+func (p *Project) Diagnostics() []diag.Diagnostic {
+	var diags []diag.Diagnostic
+	if p.parser != nil {
+		diags = append(diags, p.parser.Diagnostics()...)
+	}
+	if p.renderer != nil {
+		diags = append(diags, p.renderer.Diagnostics()...)
+	}
+	diag.Sort(diags)
+	return diags
+}
+
+// RenderStats reports how many outputs the last Generate rendered and how
+// many it reused from the previous build.
+func (p *Project) RenderStats() renderer.RenderStats {
+	if p.renderer == nil {
+		return renderer.RenderStats{}
 	}
+	return p.renderer.Stats()
+}
 
-	if err := renderer.WithEntryRenderers(
-		&renderer.DefaultRenderer{},
-	)(p.renderer); err != nil {
+// generate parses and renders the site with a new parser and renderer,
+// configured with the registered options, then opts. Files that fail to
+// parse or render don't stop the build; their errors are returned together
+// as a diag.List once the rest of the site is rendered.
+func (p *Project) generate(ctx context.Context, siteFS, themeFS, buildFS afero.Fs, opts ...renderer.RendererOption) error {
+	siteConfig, err := p.config.Map()
+	if err != nil {
 		return err
 	}
 
-	site, err := p.parser.Parse()
+	// Data is loaded once, so generators and templates see the same data.
+	data, err := renderer.LoadData(p.DataFS())
 	if err != nil {
 		return err
 	}
 
-	if err := p.renderer.Render(site); err != nil {
+	p.parser = parser.New(append(slices.Clone(p.parserOptions),
+		parser.WithSiteFS(siteFS),
+		parser.WithData(data.Values()),
+		parser.WithEntryParsers(
+			parser.NewAssetParser(p.config.Site.ContentExts...),
+			&parser.DefaultParser{},
+		),
+	)...)
+
+	rendererOptions := append(slices.Clone(p.rendererOptions),
+		renderer.WithFS(siteFS, themeFS, buildFS),
+		renderer.WithData(data),
+		renderer.WithThemeStaticDir(p.config.Theme.GetStatic()),
+		renderer.WithFingerprinting(p.config.Build.Fingerprint, p.config.Build.GetManifest()),
+		renderer.WithSiteConfig(siteConfig),
+		renderer.WithEntryRenderers(
+			&renderer.DefaultRenderer{},
+			&renderer.AssetRenderer{},
+		),
+	)
+	if n := p.config.Build.Parallelism; n > 0 {
+		rendererOptions = append(rendererOptions, renderer.WithParallelism(n))
+	}
+	p.renderer = renderer.New(append(rendererOptions, opts...)...)
+
+	var diags diag.List
+	site, err := p.parser.Parse(ctx)
+	if err != nil && !errors.As(err, &diags) {
 		return err
 	}
+	p.site = site
+
+	err = p.renderer.Render(ctx, site)
+	if err != nil && !errors.As(err, &diags) {
+		return err
+	}
+	if err := diag.Err(p.Diagnostics()); err != nil {
+		return err
+	}
+
+	if p.config.Sitemap != nil {
+		if err := p.writeSitemap(buildFS); err != nil {
+			return err
+		}
+	}
 
-	success = true
 	return nil
 }
+
+func (p *Project) writeSitemap(buildFS afero.Fs) error {
+	urls, err := sitemap.URLs(p.config.BaseURL, p.renderer.Outputs())
+	if err != nil {
+		return err
+	}
+	if err := sitemap.Write(buildFS, p.config.BaseURL, urls); err != nil {
+		return err
+	}
+	robots := p.config.Sitemap.Robots
+	if robots.Disabled {
+		return nil
+	}
+	var rules []sitemap.RobotsRule
+	for _, rule := range robots.Rules {
+		rules = append(rules, sitemap.RobotsRule(rule))
+	}
+	return sitemap.WriteRobots(buildFS, p.config.BaseURL, rules)
+}
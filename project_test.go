@@ -1,23 +1,262 @@
-package sgunk
+package sgunk_test
 
 import (
+	"bytes"
+	"context"
+	"io/fs"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/diag"
+	"github.com/connormckelvey/sgunk/extension/blog"
+	"github.com/connormckelvey/sgunk/extension/collection"
+	"github.com/connormckelvey/sgunk/sgunktest"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestProject(t *testing.T) {
-	projectFS := afero.NewBasePathFs(afero.NewOsFs(), "testdata/project1")
-	config, err := LoadConfigFile(projectFS)
+	// Build away from UTC, so the golden file also checks that the output
+	// doesn't depend on the zone of the machine.
+	local := time.Local
+	time.Local = time.FixedZone("PDT", -7*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	fixture := sgunktest.ReadDir(t, "testdata/project1")
+	project := sgunktest.Build(t, fixture,
+		sgunk.WithExtensions(&blog.Extension{}, &collection.Extension{}),
+	)
+	sgunktest.Golden(t, project.BuildFS(), "testdata/project1.golden.txtar")
+}
+
+func TestProjectFingerprint(t *testing.T) {
+	fixture := sgunktest.ReadArchive(t, "testdata/fingerprint.txtar")
+	project := sgunktest.Build(t, fixture)
+	sgunktest.Golden(t, project.BuildFS(), "testdata/fingerprint.golden.txtar")
+}
+
+func TestProjectInMemory(t *testing.T) {
+	rootFS := afero.NewMemMapFs()
+	diskFS := afero.NewBasePathFs(afero.NewOsFs(), "testdata/project1")
+	err := afero.Walk(diskFS, ".", func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if name == "_build" || name == ".sgunk" {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return nil
+		}
+		b, err := afero.ReadFile(diskFS, name)
+		if err != nil {
+			return err
+		}
+		return afero.WriteFile(rootFS, filepath.Join("/project", name), b, 0644)
+	})
+	require.NoError(t, err)
+
+	outputFS := afero.NewMemMapFs()
+	for i := 0; i < 2; i++ {
+		project := sgunk.New(
+			sgunk.WithRootFS(rootFS),
+			sgunk.WithOutputFS(outputFS),
+			sgunk.WithWorkDir("project"),
+			sgunk.WithIncremental(true),
+			sgunk.WithExtensions(&blog.Extension{}, &collection.Extension{}),
+		)
+		require.NoError(t, project.Generate(context.Background()))
+	}
+
+	ok, err := afero.Exists(outputFS, "/project/_build/index.html")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = afero.Exists(outputFS, "/project/.sgunk/deps.json")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = afero.DirExists(rootFS, "/project/_build")
 	require.NoError(t, err)
+	assert.False(t, ok)
+}
 
-	project := New(
-		WithWorkDir("testdata/project1"),
-		WithConfig(config),
+func TestProjectRepeatedBuilds(t *testing.T) {
+	rootFS := sgunktest.NewFS(t, sgunktest.ReadDir(t, "testdata/project1"))
+	project := sgunk.New(
+		sgunk.WithRootFS(rootFS),
+		sgunk.WithWorkDir("/"),
+		sgunk.WithExtensions(&blog.Extension{}, &collection.Extension{}),
 	)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, project.Generate(context.Background()))
+		sgunktest.Golden(t, project.BuildFS(), "testdata/project1.golden.txtar")
+	}
+
+	// Editing the config applies the options again.
+	config, err := afero.ReadFile(rootFS, "project.yml")
+	require.NoError(t, err)
+	config = bytes.Replace(config, []byte("name: Connor McKelvey"), []byte("name: Someone Else"), 1)
+	require.NoError(t, afero.WriteFile(rootFS, "project.yml", config, 0644))
+	require.NoError(t, project.Generate(context.Background()))
+	index, err := afero.ReadFile(project.BuildFS(), "index.html")
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "Home | Someone Else")
+}
+
+// Files that are not content are copied to the build byte for byte, under
+// their own names.
+func TestProjectAssets(t *testing.T) {
+	logo := []byte("\x89PNG\r\n\x1a\n<% not a template %>\xff")
+	project := sgunktest.Build(t, fstest.MapFS{
+		"project.yml":        {Data: []byte("name: test\nsite:\n  contentExts: [md, txt]\n")},
+		"site/index.md":      {Data: []byte("# Home\n")},
+		"site/notes.txt":     {Data: []byte("# Notes\n")},
+		"site/style.css":     {Data: []byte("body { margin: 0; }\n")},
+		"site/img/logo.png":  {Data: logo},
+		"site/page.markdown": {Data: []byte("# Not content here\n")},
+	})
+	buildFS := project.BuildFS()
 
-	err = project.Generate()
+	got, err := afero.ReadFile(buildFS, "img/logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, logo, got)
+	got, err = afero.ReadFile(buildFS, "style.css")
+	require.NoError(t, err)
+	assert.Equal(t, "body { margin: 0; }\n", string(got))
+	got, err = afero.ReadFile(buildFS, "notes.html")
+	require.NoError(t, err)
+	assert.Contains(t, string(got), "<h1>Notes</h1>")
+	_, err = afero.ReadFile(buildFS, "page.markdown")
 	assert.NoError(t, err)
+
+	for _, name := range []string{"img/logo.html", "style.html", "page.html"} {
+		_, err := buildFS.Stat(name)
+		assert.ErrorIs(t, err, fs.ErrNotExist, name)
+	}
+}
+
+// The theme's static directory is mirrored into the build root, with site
+// files taking priority.
+func TestProjectThemeStatic(t *testing.T) {
+	fixture := fstest.MapFS{
+		"project.yml":              {Data: []byte("name: test\n")},
+		"site/index.md":            {Data: []byte("# Home\n")},
+		"site/style.css":           {Data: []byte("site\n")},
+		"theme/static/style.css":   {Data: []byte("theme\n")},
+		"theme/static/fonts/a.ttf": {Data: []byte("font\n")},
+		"theme/assets/app.js":      {Data: []byte("app\n")},
+	}
+	read := func(project *sgunk.Project, name string) string {
+		t.Helper()
+		b, err := afero.ReadFile(project.BuildFS(), name)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	project := sgunktest.Build(t, fixture)
+	assert.Equal(t, "site\n", read(project, "style.css"))
+	assert.Equal(t, "font\n", read(project, "fonts/a.ttf"))
+	_, err := project.BuildFS().Stat("app.js")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	fixture["project.yml"] = &fstest.MapFile{Data: []byte("name: test\ntheme:\n  static: assets\n")}
+	project = sgunktest.Build(t, fixture)
+	assert.Equal(t, "app\n", read(project, "app.js"))
+	_, err = project.BuildFS().Stat("fonts/a.ttf")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// Options that override the config apply to every config loaded, and
+// leave the config they are applied to as it is.
+func TestProjectOverrides(t *testing.T) {
+	rootFS := sgunktest.NewFS(t, fstest.MapFS{
+		"project.yml": {Data: []byte("name: test\nsite:\n  dir: src\n")},
+	})
+	project := sgunk.New(
+		sgunk.WithRootFS(rootFS),
+		sgunk.WithWorkDir("/"),
+		sgunk.WithSiteDir("pages"),
+	)
+	require.NoError(t, project.Configure())
+	assert.Equal(t, "/pages", project.SiteDir())
+
+	require.NoError(t, afero.WriteFile(rootFS, "project.yml", []byte("name: test\nsite:\n  dir: content\n"), 0644))
+	require.NoError(t, project.Configure())
+	assert.Equal(t, "/pages", project.SiteDir())
+
+	config := &sgunk.ProjectConfig{Name: "test"}
+	project = sgunk.New(
+		sgunk.WithRootFS(rootFS),
+		sgunk.WithConfig(config),
+		sgunk.WithSiteDir("pages"),
+		sgunk.WithThemeDir("look"),
+		sgunk.WithBuildDir("out"),
+		sgunk.WithParallelism(2),
+		sgunk.WithDrafts(true),
+	)
+	require.NoError(t, project.Configure())
+	assert.Equal(t, "pages", project.SiteDir())
+	assert.Equal(t, "look", project.ThemeDir())
+	assert.Equal(t, "out", project.BuildDir())
+	assert.Equal(t, 2, project.Config().Build.Parallelism)
+	assert.True(t, project.Config().Build.Drafts)
+	assert.Equal(t, &sgunk.ProjectConfig{Name: "test"}, config)
+}
+
+// An incremental build after a plain one must start from the graph of the
+// plain build, not an older one.
+func TestProjectIncrementalAfterPlainBuild(t *testing.T) {
+	rootFS := sgunktest.NewFS(t, sgunktest.ReadDir(t, "testdata/project1"))
+	build := func(incremental bool) *sgunk.Project {
+		project := sgunk.New(
+			sgunk.WithRootFS(rootFS),
+			sgunk.WithWorkDir("/"),
+			sgunk.WithExtensions(&blog.Extension{}, &collection.Extension{}),
+			sgunk.WithIncremental(incremental),
+		)
+		require.NoError(t, project.Generate(context.Background()))
+		return project
+	}
+	original, err := afero.ReadFile(rootFS, "site/index.md")
+	require.NoError(t, err)
+	edited := bytes.Replace(original, []byte("Hello World"), []byte("Hello Edit"), 1)
+
+	build(true)
+	require.NoError(t, afero.WriteFile(rootFS, "site/index.md", edited, 0644))
+	build(false)
+	require.NoError(t, afero.WriteFile(rootFS, "site/index.md", original, 0644))
+	project := build(true)
+
+	index, err := afero.ReadFile(project.BuildFS(), "index.html")
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "Hello World")
+	assert.NotContains(t, string(index), "Hello Edit")
+}
+
+func TestProjectDiagnostics(t *testing.T) {
+	fixture := sgunktest.ReadArchive(t, "testdata/diagnostics.txtar")
+	project := sgunk.New(
+		sgunk.WithRootFS(sgunktest.NewFS(t, fixture)),
+		sgunk.WithWorkDir("/"),
+		sgunk.WithDryRun(true),
+	)
+	err := project.Generate(context.Background())
+
+	var list diag.List
+	require.ErrorAs(t, err, &list)
+	require.Len(t, list, 2)
+	assert.Equal(t, "site/bad-template.md", list[0].Path)
+	assert.Equal(t, 8, list[0].Line)
+	assert.Equal(t, 1, list[0].Column)
+	assert.Contains(t, list[0].Message, "missing is not defined")
+	assert.Equal(t, "site/bad-yaml.md", list[1].Path)
+	assert.Equal(t, 3, list[1].Line)
+
+	// The rest of the site is still rendered.
+	b, err := afero.ReadFile(project.BuildFS(), "good.html")
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "Fine.")
 }
@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/connormckelvey/sgunk/tree"
+)
+
+// AssetRenderer copies asset nodes byte for byte to the build directory,
+// at the same path they have in the site, under their published names.
+type AssetRenderer struct {
+}
+
+func (r *AssetRenderer) Kind() tree.NodeKind {
+	return tree.AssetNodeKind
+}
+
+func (r *AssetRenderer) Open(node tree.Node, context *RenderContext) error {
+	output, ok := context.manifest.Resolve(node.Path())
+	if !ok {
+		return fmt.Errorf("asset '%s' missing from manifest", node.Path())
+	}
+	if err := context.buildFS.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return err
+	}
+	if err := copyFile(context.siteFS, node.Path(), context.buildFS, output); err != nil {
+		return err
+	}
+	context.renderer.recordOutput(output, node)
+	return nil
+}
+
+func (r *AssetRenderer) Close(node tree.Node, context *RenderContext) error {
+	return nil
+}
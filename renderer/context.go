@@ -1,23 +1,92 @@
 package renderer
 
 import (
+	"context"
 	"io"
 	"io/fs"
 	"path/filepath"
+	"slices"
 
 	"github.com/connormckelvey/sgunk/tree"
 	"github.com/spf13/afero"
 )
 
+type openFile struct {
+	file afero.File
+	path string
+}
+
 type RenderContext struct {
+	ctx       context.Context
+	renderer  *Renderer
 	siteFS    afero.Fs
 	buildFS   afero.Fs
+	manifest  *AssetManifest
 	dirstack  []string
-	openFiles []afero.File
+	openFiles []openFile
+}
+
+// fork returns a context for rendering a single page. It starts in the
+// current directory but has its own directory and file stacks, so it can be
+// used concurrently with the context it was forked from.
+func (rc *RenderContext) fork() *RenderContext {
+	return &RenderContext{
+		ctx:      rc.ctx,
+		renderer: rc.renderer,
+		siteFS:   rc.siteFS,
+		buildFS:  rc.buildFS,
+		manifest: rc.manifest,
+		dirstack: slices.Clone(rc.dirstack),
+	}
+}
+
+// Context returns the context of the Render call. Entry renderers doing
+// lengthy work should stop once it is done.
+func (rc *RenderContext) Context() context.Context {
+	return rc.ctx
 }
 
 func (rc *RenderContext) Source(node tree.Node) ([]byte, error) {
-	return afero.ReadFile(rc.siteFS, node.Path())
+	return rc.renderer.source(node)
+}
+
+// RenderContent templates and compiles the source of node to HTML without
+// wrapping it in a theme, e.g. for embedding it in a feed.
+func (rc *RenderContext) RenderContent(node tree.Node) ([]byte, error) {
+	content, _, _, err := rc.renderer.renderContent(rc.ctx, node, nil)
+	return content, err
+}
+
+// RenderTemplate renders the theme template with props, following its
+// template chain like a page would. It is used for outputs that have no
+// source of their own, such as listings.
+func (rc *RenderContext) RenderTemplate(template string, props map[string]any) ([]byte, error) {
+	return wrapTheme(rc.ctx, rc.renderer.themeTemplater, template, nil, props, nil)
+}
+
+// WriteFile writes b to path, relative to the current directory, creating
+// parent directories as needed.
+func (rc *RenderContext) WriteFile(path string, b []byte) error {
+	path = filepath.Join(rc.WorkDir(), path)
+	if err := rc.buildFS.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := afero.WriteFile(rc.buildFS, path, b, 0644); err != nil {
+		return err
+	}
+	rc.renderer.recordOutput(path, nil)
+	return nil
+}
+
+// CopySource copies the source of node to path, relative to the current
+// directory, without rendering it.
+func (rc *RenderContext) CopySource(node tree.Node, path string) error {
+	path = filepath.Join(rc.WorkDir(), path)
+	if err := copyFile(rc.siteFS, node.Path(), rc.buildFS, path); err != nil {
+		return err
+	}
+	rc.renderer.recordOutput(path, node)
+	return nil
 }
 
 func (rc *RenderContext) WorkDir() string {
@@ -35,24 +104,31 @@ func (rc *RenderContext) CurrentFile() afero.File {
 	if len(rc.openFiles) == 0 {
 		return nil
 	}
-	return rc.openFiles[len(rc.openFiles)-1]
+	return rc.openFiles[len(rc.openFiles)-1].file
+}
+
+// CurrentPath returns the build path of the current file.
+func (rc *RenderContext) CurrentPath() string {
+	if len(rc.openFiles) == 0 {
+		return ""
+	}
+	return rc.openFiles[len(rc.openFiles)-1].path
 }
 
 func (rc *RenderContext) CreateFile(path string) (io.Writer, error) {
-	file, err := rc.buildFS.Create(
-		filepath.Join(rc.WorkDir(), path),
-	)
+	path = filepath.Join(rc.WorkDir(), path)
+	file, err := rc.buildFS.Create(path)
 	if err != nil {
 		return nil, err
 	}
-	rc.openFiles = append(rc.openFiles, file)
+	rc.openFiles = append(rc.openFiles, openFile{file: file, path: path})
 	return file, nil
 }
 
 func (rc *RenderContext) PopFile() afero.File {
 	popped := rc.openFiles[len(rc.openFiles)-1]
 	rc.openFiles = rc.openFiles[0 : len(rc.openFiles)-1]
-	return popped
+	return popped.file
 }
 
 func (rc *RenderContext) PushDir(dir string) {
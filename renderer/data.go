@@ -0,0 +1,159 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+const dataName = "data"
+
+// WithDataFS loads the data files in dataFS once per Render and exposes
+// them to every template as data. A nil dataFS exposes an empty object.
+func WithDataFS(dataFS afero.Fs) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.dataFS = dataFS
+		return nil
+	}
+}
+
+// WithData exposes data, already loaded with LoadData, to every template
+// instead of loading the files of the data FS, so the parts of a build
+// that read data before rendering see the same data.
+func WithData(data *Data) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.loadedData = data
+		return nil
+	}
+}
+
+// dataFiles maps the extensions of data files to their decoders.
+var dataFiles = map[string]func([]byte) (any, error){
+	".yml":  decodeYAML,
+	".yaml": decodeYAML,
+	".json": decodeJSON,
+	".toml": decodeTOML,
+	".csv":  decodeCSV,
+}
+
+func decodeYAML(b []byte) (any, error) {
+	var v any
+	err := yaml.Unmarshal(b, &v)
+	return v, err
+}
+
+func decodeJSON(b []byte) (any, error) {
+	var v any
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+func decodeTOML(b []byte) (any, error) {
+	var v map[string]any
+	err := toml.Unmarshal(b, &v)
+	return v, err
+}
+
+// decodeCSV returns a row for every record after the header, keyed by the
+// header's column names.
+func decodeCSV(b []byte) (any, error) {
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []map[string]any{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Data is the data object passed to every template. A file at
+// team/members.yml is found at data.team.members.
+type Data struct {
+	values map[string]any
+	hash   string
+}
+
+// LoadData loads the data files in dataFS like WithDataFS.
+func LoadData(dataFS afero.Fs) (*Data, error) {
+	data := &Data{values: make(map[string]any)}
+	var sources []string
+	if dataFS != nil {
+		err := afero.Walk(dataFS, ".", func(name string, info fs.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			name = path.Clean(strings.ReplaceAll(name, "\\", "/"))
+			decode, ok := dataFiles[path.Ext(name)]
+			if !ok {
+				return nil
+			}
+			b, err := afero.ReadFile(dataFS, name)
+			if err != nil {
+				return err
+			}
+			v, err := decode(b)
+			if err != nil {
+				return fmt.Errorf("data file %s: %w", name, err)
+			}
+			if err := data.set(name, v); err != nil {
+				return err
+			}
+			sources = append(sources, name+":"+hashBytes(b))
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	sort.Strings(sources)
+	data.hash = hashBytes([]byte(strings.Join(sources, "\n")))
+	return data, nil
+}
+
+// set stores v under the directories and base name of the file name.
+func (d *Data) set(name string, v any) error {
+	parts := strings.Split(strings.TrimSuffix(name, path.Ext(name)), "/")
+	m := d.values
+	for _, dir := range parts[:len(parts)-1] {
+		next, ok := m[dir].(map[string]any)
+		if !ok {
+			if _, exists := m[dir]; exists {
+				return fmt.Errorf("data file %s conflicts with %s", name, dir)
+			}
+			next = make(map[string]any)
+			m[dir] = next
+		}
+		m = next
+	}
+	key := parts[len(parts)-1]
+	if _, exists := m[key]; exists {
+		return fmt.Errorf("data file %s conflicts with another file or directory named %s", name, key)
+	}
+	m[key] = v
+	return nil
+}
+
+// Values returns the data object as templates see it.
+func (d *Data) Values() map[string]any {
+	return d.values
+}
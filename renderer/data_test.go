@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadData(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	files := map[string]string{
+		"team/members.yml": "- name: Ada\n",
+		"pricing.toml":     "[pro]\nprice = 10\n",
+		"faq.csv":          "question,answer\nWhy?,Because\n",
+		"links.json":       `{"home": "/"}`,
+		"README.md":        "ignored",
+	}
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fsys, name, []byte(content), 0644))
+	}
+
+	data, err := LoadData(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"team":    map[string]any{"members": []any{map[string]any{"name": "Ada"}}},
+		"pricing": map[string]any{"pro": map[string]any{"price": int64(10)}},
+		"faq":     []map[string]any{{"question": "Why?", "answer": "Because"}},
+		"links":   map[string]any{"home": "/"},
+	}, data.values)
+
+	require.NoError(t, afero.WriteFile(fsys, "faq.csv", []byte("question,answer\n"), 0644))
+	changed, err := LoadData(fsys)
+	require.NoError(t, err)
+	assert.NotEqual(t, data.hash, changed.hash)
+
+	require.NoError(t, afero.WriteFile(fsys, "team.yml", []byte("lead: Ada\n"), 0644))
+	_, err = LoadData(fsys)
+	assert.Error(t, err)
+}
+
+// TestDataPerPage renders pages in parallel that all change data. Each
+// page must only see its own changes; run with -race to catch pages sharing
+// it.
+func TestDataPerPage(t *testing.T) {
+	dataFS := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(dataFS, "team.yml", []byte("- name: Ada\n"), 0644))
+	data, err := LoadData(dataFS)
+	require.NoError(t, err)
+
+	buildFS := renderPerPage(t, "<% data.team[0].seen = (data.team[0].seen || '') + 'x' %>", WithData(data))
+	for i := 0; i < 20; i++ {
+		b, err := afero.ReadFile(buildFS, fmt.Sprintf("page%d.html", i))
+		require.NoError(t, err)
+		assert.Equal(t, "<p>x</p>\n", string(b), "page%d", i)
+	}
+	assert.Equal(t, []any{map[string]any{"name": "Ada"}}, data.values["team"])
+}
+
+func TestTemplateCode(t *testing.T) {
+	doc, err := (&Templater{}).parse(bytes.NewBufferString(`<div data-x="1"><% site.config.name %></div>`))
+	require.NoError(t, err)
+	code := templateCode(doc)
+	assert.Contains(t, code, "site.config.name")
+	assert.NotContains(t, code, "data-x")
+}
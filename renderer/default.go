@@ -1,6 +1,8 @@
 package renderer
 
 import (
+	"path/filepath"
+
 	"github.com/connormckelvey/sgunk/tree"
 )
 
@@ -14,10 +16,12 @@ func (r *DefaultRenderer) Kind() tree.NodeKind {
 }
 
 func (r *DefaultRenderer) openDefaultDir(node *tree.DefaultDir, context *RenderContext) error {
-	if err := context.MkdirAll(node.Path(), 0755); err != nil {
+	// The directory stack already holds the parent directories.
+	name := filepath.Base(node.Path())
+	if err := context.MkdirAll(name, 0755); err != nil {
 		return err
 	}
-	context.PushDir(node.Path())
+	context.PushDir(name)
 	return nil
 }
 
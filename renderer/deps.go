@@ -0,0 +1,196 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	SiteRoot  = "site"
+	ThemeRoot = "theme"
+	// GeneratedRoot holds the nodes that have no file in the site, hashed
+	// from their source and attributes.
+	GeneratedRoot = "generated"
+)
+
+// Input is a file an output was rendered from, along with the hash of its
+// content at the time.
+type Input struct {
+	Root string `json:"root"`
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+// DepGraph records, for every output file, the inputs that were read to
+// render it. It is persisted between builds so that outputs whose inputs
+// are unchanged can be reused instead of rendered again.
+type DepGraph struct {
+	// Fingerprint identifies the configuration the graph was built with.
+	// A graph with a different fingerprint is never reused.
+	Fingerprint string             `json:"fingerprint"`
+	Outputs     map[string][]Input `json:"outputs"`
+
+	mu sync.Mutex
+}
+
+func NewDepGraph(fingerprint string) *DepGraph {
+	return &DepGraph{
+		Fingerprint: fingerprint,
+		Outputs:     make(map[string][]Input),
+	}
+}
+
+func ReadDepGraph(fsys afero.Fs, path string) (*DepGraph, error) {
+	b, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	var g DepGraph
+	if err := json.Unmarshal(b, &g); err != nil {
+		return nil, err
+	}
+	if g.Outputs == nil {
+		g.Outputs = make(map[string][]Input)
+	}
+	return &g, nil
+}
+
+func (g *DepGraph) Write(fsys afero.Fs, path string) error {
+	g.mu.Lock()
+	b, err := json.MarshalIndent(g, "", "  ")
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := fsys.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return afero.WriteFile(fsys, path, b, 0644)
+}
+
+func (g *DepGraph) set(output string, inputs []Input) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.Outputs[output] = inputs
+}
+
+func (g *DepGraph) get(output string) ([]Input, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	inputs, ok := g.Outputs[output]
+	return inputs, ok
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// depRecorder collects the inputs read while rendering a single output.
+type depRecorder struct {
+	mu     sync.Mutex
+	inputs map[Input]struct{}
+}
+
+func newDepRecorder() *depRecorder {
+	return &depRecorder{
+		inputs: make(map[Input]struct{}),
+	}
+}
+
+func (d *depRecorder) record(root string, path string, content []byte) {
+	if d == nil {
+		return
+	}
+	d.recordHash(root, path, hashBytes(content))
+}
+
+func (d *depRecorder) recordHash(root string, path string, hash string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inputs[Input{Root: root, Path: filepath.Clean(path), Hash: hash}] = struct{}{}
+}
+
+func (d *depRecorder) list() []Input {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	inputs := make([]Input, 0, len(d.inputs))
+	for in := range d.inputs {
+		inputs = append(inputs, in)
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		if inputs[i].Root != inputs[j].Root {
+			return inputs[i].Root < inputs[j].Root
+		}
+		return inputs[i].Path < inputs[j].Path
+	})
+	return inputs
+}
+
+// hashCache memoizes input hashes for the duration of a single render so
+// shared templates are only read once.
+type hashCache struct {
+	mu     sync.Mutex
+	roots  map[string]afero.Fs
+	hashes map[string]string
+}
+
+func newHashCache(roots map[string]afero.Fs) *hashCache {
+	return &hashCache{
+		roots:  roots,
+		hashes: make(map[string]string),
+	}
+}
+
+func (c *hashCache) hash(root string, path string) (string, error) {
+	key := root + ":" + path
+	c.mu.Lock()
+	h, ok := c.hashes[key]
+	c.mu.Unlock()
+	if ok {
+		return h, nil
+	}
+
+	fsys, ok := c.roots[root]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	b, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		return "", err
+	}
+	h = hashBytes(b)
+
+	c.mu.Lock()
+	c.hashes[key] = h
+	c.mu.Unlock()
+	return h, nil
+}
+
+// set records the hash of an input that is not read from a filesystem.
+func (c *hashCache) set(root string, path string, hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hashes[root+":"+path] = hash
+}
+
+// fresh reports whether every input still hashes to its recorded value.
+func (c *hashCache) fresh(inputs []Input) bool {
+	for _, in := range inputs {
+		h, err := c.hash(in.Root, in.Path)
+		if err != nil || h != in.Hash {
+			return false
+		}
+	}
+	return true
+}
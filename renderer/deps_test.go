@@ -0,0 +1,33 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepGraphFreshness(t *testing.T) {
+	siteFS := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(siteFS, "index.md", []byte("hello"), 0644))
+
+	deps := newDepRecorder()
+	deps.record(SiteRoot, "index.md", []byte("hello"))
+
+	graph := NewDepGraph("fp")
+	graph.set("index.html", deps.list())
+
+	cacheFS := afero.NewMemMapFs()
+	require.NoError(t, graph.Write(cacheFS, ".sgunk/deps.json"))
+	read, err := ReadDepGraph(cacheFS, ".sgunk/deps.json")
+	require.NoError(t, err)
+	assert.Equal(t, "fp", read.Fingerprint)
+
+	inputs, ok := read.get("index.html")
+	require.True(t, ok)
+	assert.True(t, newHashCache(map[string]afero.Fs{SiteRoot: siteFS}).fresh(inputs))
+
+	require.NoError(t, afero.WriteFile(siteFS, "index.md", []byte("changed"), 0644))
+	assert.False(t, newHashCache(map[string]afero.Fs{SiteRoot: siteFS}).fresh(inputs))
+}
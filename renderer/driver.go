@@ -0,0 +1,69 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/connormckelvey/tmplrun/evaluator"
+	"github.com/dop251/goja"
+)
+
+// gojaDriver evaluates template code like the goja driver of tmplrun, but
+// interrupts scripts that are still running when ctx is done.
+type gojaDriver struct {
+	ctx context.Context
+}
+
+func newGojaDriver(ctx context.Context) *gojaDriver {
+	return &gojaDriver{ctx: ctx}
+}
+
+func (d *gojaDriver) CreateContext(env *evaluator.Environment) (evaluator.DriverContext, error) {
+	vm := goja.New()
+	for k, v := range env.Props() {
+		if err := vm.Set(k, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := vm.Set("include", env.Include); err != nil {
+		return nil, err
+	}
+	if err := vm.Set("template", env.Template); err != nil {
+		return nil, err
+	}
+	if err := vm.Set("log", func(args ...any) {
+		fmt.Println(args...)
+	}); err != nil {
+		return nil, err
+	}
+	return &gojaContext{ctx: d.ctx, vm: vm}, nil
+}
+
+type gojaContext struct {
+	ctx context.Context
+	vm  *goja.Runtime
+}
+
+func (gc *gojaContext) Eval(code string) (string, error) {
+	if err := gc.ctx.Err(); err != nil {
+		return "", err
+	}
+	stop := context.AfterFunc(gc.ctx, func() {
+		gc.vm.Interrupt(gc.ctx.Err())
+	})
+	defer stop()
+
+	v, err := gc.vm.RunString(code)
+	if err != nil {
+		// Report the cause rather than where the script was stopped.
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			if cause, ok := interrupted.Value().(error); ok {
+				return "", cause
+			}
+		}
+		return "", err
+	}
+	return fmt.Sprint(v.Export()), nil
+}
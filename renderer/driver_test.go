@@ -0,0 +1,26 @@
+package renderer
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplaterInterrupted(t *testing.T) {
+	tr := NewTemplater(fstest.MapFS{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := tr.Render(ctx, strings.NewReader("<% while (true) {} %>"), "index.md", nil, io.Discard)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var b strings.Builder
+	err = tr.Render(context.Background(), strings.NewReader("<% 1 + 1 %>"), "index.md", nil, &b)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", b.String())
+}
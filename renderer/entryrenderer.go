@@ -7,3 +7,10 @@ type EntryRenderer interface {
 	Open(node tree.Node, context *RenderContext) error
 	Close(node tree.Node, context *RenderContext) error
 }
+
+// Finisher is implemented by entry renderers that write outputs spanning
+// many nodes, such as indexes or feeds. Finish is called once every page
+// has been rendered.
+type Finisher interface {
+	Finish(site *tree.Site, context *RenderContext) error
+}
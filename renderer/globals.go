@@ -0,0 +1,64 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/connormckelvey/tmplrun/ast"
+)
+
+// GlobalsRoot names the objects passed to every template, such as site and
+// data, in the dependency graph. Each is recorded with a hash of its whole
+// content, so an output rendered from a template that uses one depends on
+// everything it holds.
+const GlobalsRoot = "globals"
+
+// globalHashes returns the hash of every global of the current render,
+// keyed by the name templates use.
+func (r *Renderer) globalHashes() map[string]string {
+	hashes := make(map[string]string)
+	if r.site != nil {
+		hashes[siteDataName] = r.site.hash
+	}
+	if r.data != nil {
+		hashes[dataName] = r.data.hash
+	}
+	return hashes
+}
+
+// recordGlobalUse records a dependency on every global whose name appears
+// in the code of doc. Matching names is coarse, but only ever records too
+// much.
+func (r *Renderer) recordGlobalUse(doc *ast.Document, deps *depRecorder) {
+	if deps == nil {
+		return
+	}
+	code := templateCode(doc)
+	for name, hash := range r.globalHashes() {
+		if regexp.MustCompile(`\b` + name + `\b`).MatchString(code) {
+			deps.recordHash(GlobalsRoot, name, hash)
+		}
+	}
+}
+
+// templateCode returns the code inside the template tags of doc, leaving
+// out the text around them.
+func templateCode(doc *ast.Document) string {
+	var b strings.Builder
+	var walk func(nodes []ast.Node, inTag bool)
+	walk = func(nodes []ast.Node, inTag bool) {
+		for _, node := range nodes {
+			switch n := node.(type) {
+			case *ast.TemplateNode:
+				walk(n.Children(), true)
+			case *ast.TextNode:
+				if inTag {
+					b.WriteString(n.Token.Literal)
+					b.WriteByte('\n')
+				}
+			}
+		}
+	}
+	walk(doc.Children(), false)
+	return b.String()
+}
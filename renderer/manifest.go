@@ -0,0 +1,161 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/connormckelvey/sgunk/tree"
+	"github.com/spf13/afero"
+)
+
+const fingerprintLen = 8
+
+type manifestEntry struct {
+	root   string
+	source string
+	output string
+	hash   string
+}
+
+// AssetManifest maps the logical name of every asset, its path relative to
+// the build root, to the path it is published under. Without fingerprinting
+// the two are the same.
+type AssetManifest struct {
+	entries map[string]manifestEntry
+}
+
+func newAssetManifest() *AssetManifest {
+	return &AssetManifest{
+		entries: make(map[string]manifestEntry),
+	}
+}
+
+// assetName cleans a logical asset name, so "/css/style.css" and
+// "css/./style.css" resolve the same.
+func assetName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// fingerprintName inserts hash before the extension of name.
+func fingerprintName(name string, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash[:fingerprintLen] + ext
+}
+
+func (m *AssetManifest) has(name string) bool {
+	_, ok := m.entries[assetName(name)]
+	return ok
+}
+
+func (m *AssetManifest) add(name string, root string, source string, fsys afero.Fs, fingerprint bool) error {
+	name = assetName(name)
+	entry := manifestEntry{
+		root:   root,
+		source: source,
+		output: name,
+	}
+	if fingerprint {
+		b, err := afero.ReadFile(fsys, source)
+		if err != nil {
+			return err
+		}
+		entry.hash = hashBytes(b)
+		entry.output = fingerprintName(name, entry.hash)
+	}
+	m.entries[name] = entry
+	return nil
+}
+
+// Resolve returns the published path of the asset with the given logical
+// name, relative to the build root.
+func (m *AssetManifest) Resolve(name string) (string, bool) {
+	entry, ok := m.entries[assetName(name)]
+	if !ok {
+		return "", false
+	}
+	return entry.output, true
+}
+
+func (m *AssetManifest) MarshalJSON() ([]byte, error) {
+	out := make(map[string]string, len(m.entries))
+	for name, entry := range m.entries {
+		out[name] = entry.output
+	}
+	return json.Marshal(out)
+}
+
+// buildManifest collects the site's assets, then the theme's static files
+// that the site does not override.
+func (r *Renderer) buildManifest(site tree.Node) error {
+	r.manifest = newAssetManifest()
+
+	var walk func(n tree.Node) error
+	walk = func(n tree.Node) error {
+		if n.Kind() == tree.AssetNodeKind {
+			if err := r.manifest.add(n.Path(), SiteRoot, n.Path(), r.siteFS, r.fingerprint); err != nil {
+				return err
+			}
+		}
+		for _, child := range n.Children() {
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(site); err != nil {
+		return err
+	}
+
+	if r.themeFS == nil || r.themeStaticDir == "" {
+		return nil
+	}
+	if _, err := r.themeFS.Stat(r.themeStaticDir); os.IsNotExist(err) {
+		return nil
+	}
+	return afero.Walk(r.themeFS, r.themeStaticDir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(r.themeStaticDir, path)
+		if err != nil {
+			return err
+		}
+		if r.manifest.has(rel) {
+			return nil
+		}
+		return r.manifest.add(rel, ThemeRoot, path, r.themeFS, r.fingerprint)
+	})
+}
+
+func (r *Renderer) writeManifest() error {
+	if !r.fingerprint || r.manifestPath == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(r.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(r.buildFS, r.manifestPath, b, 0644)
+}
+
+// assetHelper returns the asset() template function, which resolves a
+// logical asset name to its published URL and records the asset as a
+// dependency of the page being rendered.
+func (r *Renderer) assetHelper(deps *depRecorder) func(name string) (string, error) {
+	return func(name string) (string, error) {
+		entry, ok := r.manifest.entries[assetName(name)]
+		if !ok {
+			return "", fmt.Errorf("asset: unknown asset '%s'", name)
+		}
+		if entry.hash != "" {
+			deps.recordHash(entry.root, entry.source, entry.hash)
+		}
+		return "/" + entry.output, nil
+	}
+}
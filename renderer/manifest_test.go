@@ -0,0 +1,43 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprintName(t *testing.T) {
+	hash := hashBytes([]byte("body {}"))
+	assert.Equal(t, "style."+hash[:8]+".css", fingerprintName("style.css", hash))
+	assert.Equal(t, "css/LICENSE."+hash[:8], fingerprintName("css/LICENSE", hash))
+}
+
+func TestAssetName(t *testing.T) {
+	assert.Equal(t, "css/style.css", assetName("/css/./style.css"))
+	assert.Equal(t, "style.css", assetName("../style.css"))
+}
+
+func TestAssetHelper(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fsys, "css/style.css", []byte("body {}"), 0644))
+	hash := hashBytes([]byte("body {}"))
+
+	r := &Renderer{manifest: newAssetManifest()}
+	require.NoError(t, r.manifest.add("css/style.css", SiteRoot, "css/style.css", fsys, true))
+	b, err := r.manifest.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"css/style.css": "css/style.`+hash[:8]+`.css"}`, string(b))
+
+	deps := newDepRecorder()
+	asset := r.assetHelper(deps)
+	url, err := asset("/css/style.css")
+	require.NoError(t, err)
+	assert.Equal(t, "/css/style."+hash[:8]+".css", url)
+	// Pages linking a fingerprinted asset depend on its content.
+	assert.Equal(t, []Input{{Root: SiteRoot, Path: "css/style.css", Hash: hash}}, deps.list())
+
+	_, err = asset("missing.css")
+	assert.Error(t, err)
+}
@@ -0,0 +1,61 @@
+package renderer
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/connormckelvey/sgunk/tree"
+)
+
+// Output is a file written to the build directory by Render, other than
+// the theme's static files.
+type Output struct {
+	// Path is slash separated and relative to the build root.
+	Path string
+	// Node is the site node the output was rendered from, or nil for
+	// outputs spanning many nodes, such as listings and feeds.
+	Node tree.Node
+	// LastMod is when the source of Node last changed. It is zero when
+	// Node is nil.
+	LastMod time.Time
+}
+
+// PermalinkFile returns the file a permalink is written to, relative to the
+// build root. Permalinks ending in a slash are directories served by their
+// index.html.
+func PermalinkFile(permalink string) string {
+	if permalink == "" || strings.HasSuffix(permalink, "/") {
+		return path.Join(permalink, "index.html")
+	}
+	return permalink
+}
+
+func (r *Renderer) recordOutput(path string, node tree.Node) {
+	output := Output{
+		Path: filepath.ToSlash(filepath.Clean(path)),
+		Node: node,
+	}
+	if _, generated := node.(tree.Generated); node != nil && !generated {
+		if info, err := r.siteFS.Stat(node.Path()); err == nil {
+			output.LastMod = info.ModTime()
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outputs = append(r.outputs, output)
+}
+
+// Outputs returns the files written by the last Render, sorted by path.
+func (r *Renderer) Outputs() []Output {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	outputs := make([]Output, len(r.outputs))
+	copy(outputs, r.outputs)
+	sort.Slice(outputs, func(i, j int) bool {
+		return outputs[i].Path < outputs[j].Path
+	})
+	return outputs
+}
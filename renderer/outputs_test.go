@@ -0,0 +1,13 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermalinkFile(t *testing.T) {
+	assert.Equal(t, "index.html", PermalinkFile(""))
+	assert.Equal(t, "2024/04/hello/index.html", PermalinkFile("2024/04/hello/"))
+	assert.Equal(t, "2024/04/09/hello.html", PermalinkFile("2024/04/09/hello.html"))
+}
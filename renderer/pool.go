@@ -0,0 +1,59 @@
+package renderer
+
+import (
+	"sync"
+)
+
+// workerPool runs submitted jobs on at most size goroutines. After the
+// first job fails, jobs that have not started yet are skipped.
+type workerPool struct {
+	sem chan struct{}
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+	err error
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size < 1 {
+		size = 1
+	}
+	return &workerPool{
+		sem: make(chan struct{}, size),
+	}
+}
+
+func (p *workerPool) failed() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func (p *workerPool) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// submit blocks until a worker is free, then runs job on it.
+func (p *workerPool) submit(job func() error) {
+	p.wg.Add(1)
+	p.sem <- struct{}{}
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.sem }()
+		if p.failed() != nil {
+			return
+		}
+		if err := job(); err != nil {
+			p.fail(err)
+		}
+	}()
+}
+
+// wait blocks until every submitted job is done and returns the first error.
+func (p *workerPool) wait() error {
+	p.wg.Wait()
+	return p.failed()
+}
@@ -0,0 +1,122 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/connormckelvey/sgunk/diag"
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPoolBounded(t *testing.T) {
+	pool := newWorkerPool(3)
+	var running, peak, done atomic.Int32
+	for i := 0; i < 20; i++ {
+		pool.submit(func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+			return nil
+		})
+	}
+	require.NoError(t, pool.wait())
+	assert.Equal(t, int32(20), done.Load())
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+}
+
+func TestWorkerPoolFirstError(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	pool := newWorkerPool(1)
+	pool.submit(func() error { return first })
+	// The single worker is only free once the first job has failed, so the
+	// jobs after it are skipped.
+	var ran atomic.Bool
+	pool.submit(func() error {
+		ran.Store(true)
+		return second
+	})
+	assert.ErrorIs(t, pool.wait(), first)
+	assert.ErrorIs(t, pool.failed(), first)
+	assert.False(t, ran.Load())
+}
+
+// renderParallel renders a site of many pages, some of them broken, with n
+// pages rendered concurrently.
+func renderParallel(t *testing.T, ctx context.Context, n int) (afero.Fs, error) {
+	t.Helper()
+	// Rooted so relative and absolute names refer to the same files.
+	memFS := func() afero.Fs { return afero.NewBasePathFs(afero.NewMemMapFs(), "/") }
+	siteFS, themeFS, buildFS := memFS(), memFS(), memFS()
+	require.NoError(t, afero.WriteFile(themeFS, "main.html", []byte("<main><% $outlet %></main>"), 0644))
+	for i := 0; i < 30; i++ {
+		body := fmt.Sprintf("# Page <%% %d * 2 %%>", i)
+		if i%10 == 3 {
+			body = "<% missing.value %>"
+		}
+		src := "---\npage:\n  template: main.html\n---\n\n" + body + "\n"
+		path := fmt.Sprintf("dir%d/page%d.md", i%4, i)
+		require.NoError(t, siteFS.MkdirAll(fmt.Sprintf("dir%d", i%4), 0755))
+		require.NoError(t, afero.WriteFile(siteFS, path, []byte(src), 0644))
+	}
+
+	site, err := parser.New(
+		parser.WithSiteFS(siteFS),
+		parser.WithEntryParsers(parser.NewAssetParser(), &parser.DefaultParser{}),
+	).Parse(context.Background())
+	require.NoError(t, err)
+
+	err = New(
+		WithFS(siteFS, themeFS, buildFS),
+		WithEntryRenderers(&DefaultRenderer{}, &AssetRenderer{}),
+		WithParallelism(n),
+	).Render(ctx, site)
+	return buildFS, err
+}
+
+func TestRenderParallelism(t *testing.T) {
+	serialFS, serialErr := renderParallel(t, context.Background(), 1)
+	parallelFS, parallelErr := renderParallel(t, context.Background(), 8)
+
+	var list diag.List
+	require.ErrorAs(t, serialErr, &list)
+	assert.Len(t, list, 3)
+	assert.Equal(t, serialErr, parallelErr)
+
+	var files int
+	err := afero.Walk(serialFS, ".", func(path string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		files++
+		want, err := afero.ReadFile(serialFS, path)
+		require.NoError(t, err)
+		got, err := afero.ReadFile(parallelFS, path)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), path)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 30, files)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, n := range []int{1, 8} {
+		_, err := renderParallel(t, ctx, n)
+		assert.ErrorIs(t, err, context.Canceled, "-j %d", n)
+	}
+}
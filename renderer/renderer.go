@@ -2,9 +2,17 @@ package renderer
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
 
 	"github.com/adrg/frontmatter"
+	"github.com/connormckelvey/sgunk/diag"
 	"github.com/connormckelvey/sgunk/tree"
 	"github.com/spf13/afero"
 	"github.com/yuin/goldmark"
@@ -13,13 +21,60 @@ import (
 )
 
 type Renderer struct {
-	options   []RendererOption
-	siteFS    afero.Fs
-	themeFS   afero.Fs
-	buildFS   afero.Fs
-	renderers map[tree.NodeKind]EntryRenderer
-	templater *Templater
-	markdown  goldmark.Markdown
+	options    []RendererOption
+	configured bool
+	siteFS     afero.Fs
+	themeFS    afero.Fs
+	buildFS    afero.Fs
+	renderers  map[tree.NodeKind]EntryRenderer
+	templater  *Templater
+	markdown   goldmark.Markdown
+
+	themeTemplater *Templater
+	helpers        map[string]any
+
+	// siteConfig and site make up the site object passed to every
+	// template.
+	siteConfig map[string]any
+	site       *siteData
+
+	// dataFS holds the data files loaded into data for every Render,
+	// unless loadedData was passed in.
+	dataFS     afero.Fs
+	loadedData *Data
+	data       *Data
+
+	// fingerprint publishes assets under names containing a hash of their
+	// content, listed in the manifest written to manifestPath.
+	fingerprint  bool
+	manifestPath string
+	manifest     *AssetManifest
+
+	// themeStaticDir is the directory in themeFS mirrored into the build
+	// root after the site is rendered.
+	themeStaticDir string
+
+	// previous and previousFS hold the dependency graph and output of the
+	// last build. Outputs whose inputs are unchanged are copied from
+	// previousFS instead of being rendered again.
+	previous   *DepGraph
+	previousFS afero.Fs
+	graph      *DepGraph
+	hashes     *hashCache
+
+	parallelism int
+	mu          sync.Mutex
+	stats       RenderStats
+	outputs     []Output
+
+	// diags records the pages and finishers that failed to render.
+	diags *diag.Collector
+}
+
+// RenderStats counts how outputs were produced by the last Render.
+type RenderStats struct {
+	Rendered int
+	Reused   int
 }
 
 type RendererOption interface {
@@ -47,7 +102,12 @@ func WithEntryRenderers(renderers ...EntryRenderer) RendererOptionFunc {
 func WithSiteFS(siteFS afero.Fs) RendererOptionFunc {
 	return func(r *Renderer) error {
 		r.siteFS = siteFS
-		r.templater = NewTemplater(afero.NewIOFS(siteFS))
+		r.templater = &Templater{
+			fs:      afero.NewIOFS(siteFS),
+			root:    SiteRoot,
+			helpers: r.templateHelpers,
+			scan:    r.recordGlobalUse,
+		}
 		return nil
 	}
 }
@@ -55,6 +115,12 @@ func WithSiteFS(siteFS afero.Fs) RendererOptionFunc {
 func WithThemeFS(themeFS afero.Fs) RendererOptionFunc {
 	return func(r *Renderer) error {
 		r.themeFS = themeFS
+		r.themeTemplater = &Templater{
+			fs:      afero.NewIOFS(themeFS),
+			root:    ThemeRoot,
+			helpers: r.templateHelpers,
+			scan:    r.recordGlobalUse,
+		}
 		return nil
 	}
 }
@@ -66,6 +132,55 @@ func WithBuildFS(buildFS afero.Fs) RendererOptionFunc {
 	}
 }
 
+// WithTemplateHelper exposes fn to every template under name.
+func WithTemplateHelper(name string, fn any) RendererOptionFunc {
+	return func(r *Renderer) error {
+		if r.helpers == nil {
+			r.helpers = make(map[string]any)
+		}
+		r.helpers[name] = fn
+		return nil
+	}
+}
+
+// WithFingerprinting publishes assets under content hashed names and writes
+// a manifest mapping logical names to published ones to manifestPath in the
+// build directory.
+func WithFingerprinting(enabled bool, manifestPath string) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.fingerprint = enabled
+		r.manifestPath = manifestPath
+		return nil
+	}
+}
+
+func WithThemeStaticDir(dir string) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.themeStaticDir = dir
+		return nil
+	}
+}
+
+// WithParallelism sets how many pages are rendered concurrently. Values
+// below one render on a single goroutine.
+func WithParallelism(n int) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.parallelism = n
+		return nil
+	}
+}
+
+// WithPreviousBuild enables incremental rendering against the dependency
+// graph and build output of a previous render. The graph passed here is
+// only read; the graph for the new build is available from DepGraph.
+func WithPreviousBuild(graph *DepGraph, buildFS afero.Fs) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.previous = graph
+		r.previousFS = buildFS
+		return nil
+	}
+}
+
 func WithFS(siteFS afero.Fs, themeFS afero.Fs, buildFS afero.Fs) RendererOptionFunc {
 	opts := []RendererOptionFunc{
 		WithSiteFS(siteFS),
@@ -84,7 +199,8 @@ func WithFS(siteFS afero.Fs, themeFS afero.Fs, buildFS afero.Fs) RendererOptionF
 
 func New(opts ...RendererOption) *Renderer {
 	return &Renderer{
-		options: opts,
+		options:     opts,
+		parallelism: runtime.NumCPU(),
 		markdown: goldmark.New(
 			goldmark.WithExtensions(extension.GFM),
 			goldmark.WithRendererOptions(html.WithUnsafe()),
@@ -92,38 +208,220 @@ func New(opts ...RendererOption) *Renderer {
 	}
 }
 
-func (r *Renderer) Render(site *tree.Site) error {
-	for _, opt := range r.options {
-		err := opt.Apply(r)
-		if err != nil {
+// Render renders site into the build file system. The options passed to
+// New are applied by the first call; every call starts from fresh outputs,
+// stats and dependency graph. Rendering stops with ctx's error once ctx is
+// done.
+//
+// Pages and finishers that fail are recorded in Diagnostics and the rest of
+// the site is rendered. Render then returns a diag.List of the errors.
+func (r *Renderer) Render(ctx context.Context, site *tree.Site) error {
+	if !r.configured {
+		for _, opt := range r.options {
+			err := opt.Apply(r)
+			if err != nil {
+				return err
+			}
+		}
+		r.configured = true
+	}
+
+	siteData, err := newSiteData(site, r.siteConfig)
+	if err != nil {
+		return err
+	}
+	r.site = siteData
+
+	r.data = r.loadedData
+	if r.data == nil {
+		if r.data, err = LoadData(r.dataFS); err != nil {
 			return err
 		}
 	}
 
-	return r.render(site, &RenderContext{
-		siteFS:  r.siteFS,
-		buildFS: r.buildFS,
+	r.graph = NewDepGraph("")
+	r.hashes = newHashCache(map[string]afero.Fs{
+		SiteRoot:  r.siteFS,
+		ThemeRoot: r.themeFS,
 	})
+	for name, hash := range r.globalHashes() {
+		r.hashes.set(GlobalsRoot, name, hash)
+	}
+	if err := r.hashGenerated(site); err != nil {
+		return err
+	}
+	r.stats = RenderStats{}
+	r.outputs = nil
+	r.diags = &diag.Collector{}
+
+	if err := r.buildManifest(site); err != nil {
+		return err
+	}
+
+	pool := newWorkerPool(r.parallelism)
+	err = r.render(site, r.newContext(ctx), pool)
+	if waitErr := pool.wait(); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.finish(ctx, site); err != nil {
+		return err
+	}
+	if err := r.copyThemeStatic(); err != nil {
+		return err
+	}
+	if err := r.writeManifest(); err != nil {
+		return err
+	}
+	return r.diags.Err()
+}
+
+// Diagnostics returns the errors of the last Render.
+func (r *Renderer) Diagnostics() []diag.Diagnostic {
+	if r.diags == nil {
+		return nil
+	}
+	return r.diags.Diagnostics()
+}
+
+// finish calls every entry renderer that implements Finisher, in order of
+// kind, once all pages are rendered.
+func (r *Renderer) finish(ctx context.Context, site *tree.Site) error {
+	kinds := make([]tree.NodeKind, 0, len(r.renderers))
+	for kind := range r.renderers {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	for _, kind := range kinds {
+		finisher, ok := r.renderers[kind].(Finisher)
+		if !ok {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := finisher.Finish(site, r.newContext(ctx)); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			r.diags.Error(kind.String(), err)
+		}
+	}
+	return nil
+}
+
+func (r *Renderer) newContext(ctx context.Context) *RenderContext {
+	return &RenderContext{
+		ctx:      ctx,
+		renderer: r,
+		siteFS:   r.siteFS,
+		buildFS:  r.buildFS,
+		manifest: r.manifest,
+	}
 }
 
-func (r *Renderer) render(root tree.Node, context *RenderContext) error {
-	renderer, ok := r.renderers[root.Kind()]
+func (r *Renderer) templateHelpers(deps *depRecorder) map[string]any {
+	helpers := make(map[string]any, len(r.helpers)+3)
+	for name, fn := range r.helpers {
+		helpers[name] = fn
+	}
+	helpers["asset"] = r.assetHelper(deps)
+	// Templates can change the objects they are given, so every run gets
+	// copies of its own.
+	if r.site != nil {
+		helpers[siteDataName] = r.site.props()
+	}
+	if r.data != nil {
+		helpers[dataName] = cloneValue(r.data.values)
+	}
+	return helpers
+}
+
+// DepGraph returns the dependency graph recorded by the last Render.
+func (r *Renderer) DepGraph() *DepGraph {
+	return r.graph
+}
+
+func (r *Renderer) Stats() RenderStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+func (r *Renderer) entryRenderer(node tree.Node) EntryRenderer {
+	renderer, ok := r.renderers[node.Kind()]
 	if !ok {
-		renderer = defaultRenderer
+		return defaultRenderer
+	}
+	return renderer
+}
+
+// render opens and closes directories on the calling goroutine, so the
+// directory stack is built in tree order, and hands every page to the pool
+// with a context of its own.
+func (r *Renderer) render(root tree.Node, context *RenderContext, pool *workerPool) error {
+	if !root.IsDir() {
+		pageContext := context.fork()
+		pool.submit(func() error {
+			return r.renderPage(root, pageContext)
+		})
+		return nil
+	}
+
+	renderer := r.entryRenderer(root)
+	if err := renderer.Open(root, context); err != nil {
+		return err
+	}
+
+	for _, child := range root.Children() {
+		if err := pool.failed(); err != nil {
+			return err
+		}
+		if err := context.ctx.Err(); err != nil {
+			return err
+		}
+		if err := r.render(child, context, pool); err != nil {
+			return err
+		}
+	}
+
+	return renderer.Close(root, context)
+}
+
+func (r *Renderer) renderPage(root tree.Node, context *RenderContext) error {
+	if err := context.ctx.Err(); err != nil {
+		return err
 	}
+	renderer := r.entryRenderer(root)
 	if err := renderer.Open(root, context); err != nil {
 		return err
 	}
 
 	for _, child := range root.Children() {
-		if err := r.render(child, context); err != nil {
+		if err := r.renderPage(child, context); err != nil {
 			return err
 		}
 	}
 
 	if currentFile := context.CurrentFile(); !root.IsDir() && currentFile != nil {
-		if err := r.renderCurrentFile(root, context); err != nil {
+		reused, err := r.reuseCurrentFile(context)
+		if err != nil {
+			return err
+		}
+		if !reused {
+			err = r.renderCurrentFile(root, context)
+		}
+		switch {
+		case err == nil:
+			r.recordOutput(context.CurrentPath(), root)
+		case context.ctx.Err() != nil:
 			return err
+		default:
+			// The page is left empty so the rest of the site still renders.
+			r.diags.Error(nodePath(root), err)
 		}
 	}
 
@@ -134,7 +432,71 @@ func (r *Renderer) render(root tree.Node, context *RenderContext) error {
 	return nil
 }
 
+// reuseCurrentFile copies the current file from the previous build when
+// none of the inputs it was rendered from have changed.
+func (r *Renderer) reuseCurrentFile(context *RenderContext) (bool, error) {
+	if r.previous == nil || r.previousFS == nil {
+		return false, nil
+	}
+	output := context.CurrentPath()
+	inputs, ok := r.previous.get(output)
+	if !ok || !r.hashes.fresh(inputs) {
+		return false, nil
+	}
+	prev, err := r.previousFS.Open(output)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer prev.Close()
+
+	if _, err := io.Copy(context.CurrentFile(), prev); err != nil {
+		return false, err
+	}
+	r.graph.set(output, inputs)
+	r.mu.Lock()
+	r.stats.Reused++
+	r.mu.Unlock()
+	return true, nil
+}
+
 func (r *Renderer) renderCurrentFile(root tree.Node, context *RenderContext) error {
+	deps := newDepRecorder()
+	if err := r.renderCurrentFileTracked(root, context, deps); err != nil {
+		return err
+	}
+	r.graph.set(context.CurrentPath(), deps.list())
+	r.mu.Lock()
+	r.stats.Rendered++
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Renderer) renderCurrentFileTracked(root tree.Node, context *RenderContext, deps *depRecorder) error {
+	content, props, template, err := r.renderContent(context.ctx, root, deps)
+	if err != nil {
+		return err
+	}
+	if template == "" {
+		_, err := context.CurrentFile().Write(content)
+		return err
+	}
+	b, err := wrapTheme(context.ctx, r.themeTemplater, template, content, props, deps)
+	if err != nil {
+		return err
+	}
+	if _, err := context.CurrentFile().Write(b); err != nil {
+		return err
+	}
+	return nil
+}
+
+// renderContent templates the source of root and compiles it to HTML,
+// without wrapping it in a theme. It also returns the props root was
+// templated with and the theme template its front matter asks for.
+func (r *Renderer) renderContent(ctx context.Context, root tree.Node, deps *depRecorder) ([]byte, map[string]any, string, error) {
 	// TODO .Props method on renderer makes no sense
 	// It shouldn't be a method at all. Just something
 	// done during parsing and attached to the node.
@@ -143,9 +505,18 @@ func (r *Renderer) renderCurrentFile(root tree.Node, context *RenderContext) err
 	// Want to find a way to make parsers, or some other type composable
 	// so that multiple things can attach their own props.
 	// Page props, Post props
-	source, err := context.Source(root)
+	source, err := r.source(root)
 	if err != nil {
-		return err
+		return nil, nil, "", err
+	}
+	if _, ok := root.(tree.Generated); ok {
+		hash, err := r.hashes.hash(GeneratedRoot, root.Path())
+		if err != nil {
+			return nil, nil, "", err
+		}
+		deps.recordHash(GeneratedRoot, root.Path(), hash)
+	} else {
+		deps.record(SiteRoot, root.Path(), source)
 	}
 
 	var fm struct {
@@ -153,37 +524,82 @@ func (r *Renderer) renderCurrentFile(root tree.Node, context *RenderContext) err
 	}
 	content, err := frontmatter.Parse(bytes.NewReader(source), &fm)
 	if err != nil {
-		return err
+		return nil, nil, "", diag.At(diag.FrontMatter(err), nodePath(root), 0)
 	}
 
-	nodeAttrs, err := root.Attributes()
+	props, err := nodeProps(root)
 	if err != nil {
-		return err
-	}
-
-	props := make(map[string]any)
-	for k, v := range nodeAttrs {
-		props[k] = v
+		return nil, nil, "", err
 	}
 
 	var templated bytes.Buffer
-	if err := r.templater.Render(bytes.NewReader(content), root.Path(), props, &templated); err != nil {
-		return err
+	if err := r.templater.renderTracked(ctx, bytes.NewReader(content), root.Path(), props, &templated, deps); err != nil {
+		return nil, nil, "", diag.At(err, nodePath(root), frontMatterLines(source, content))
 	}
 	var compiledMarkdown bytes.Buffer
 	if err := r.markdown.Convert(templated.Bytes(), &compiledMarkdown); err != nil {
-		return err
+		return nil, nil, "", diag.At(err, nodePath(root), 0)
 	}
-	if fm.Page.Template == "" {
-		_, err := io.Copy(context.CurrentFile(), &compiledMarkdown)
-		return err
+	return compiledMarkdown.Bytes(), props, fm.Page.Template, nil
+}
+
+// nodePath returns the path diagnostics about node are reported under.
+func nodePath(node tree.Node) string {
+	root := SiteRoot
+	if _, ok := node.(tree.Generated); ok {
+		root = GeneratedRoot
 	}
-	b, err := WrapTheme(r.themeFS, fm.Page.Template, compiledMarkdown.Bytes(), props)
-	if err != nil {
-		return err
+	return root + "/" + filepath.ToSlash(node.Path())
+}
+
+// frontMatterLines returns the number of lines of source before content,
+// the part of source left after its front matter.
+func frontMatterLines(source []byte, content []byte) int {
+	return bytes.Count(source, []byte("\n")) - bytes.Count(content, []byte("\n"))
+}
+
+// source returns the source of node, read from the site unless node is
+// generated.
+func (r *Renderer) source(node tree.Node) ([]byte, error) {
+	if generated, ok := node.(tree.Generated); ok {
+		return generated.Source(), nil
 	}
-	if _, err := context.CurrentFile().Write(b); err != nil {
-		return err
+	return afero.ReadFile(r.siteFS, node.Path())
+}
+
+// hashGenerated hashes the source and attributes of every generated node
+// under root, standing in for the file hash of site nodes.
+func (r *Renderer) hashGenerated(root tree.Node) error {
+	if generated, ok := root.(tree.Generated); ok {
+		props, err := nodeProps(generated)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(props)
+		if err != nil {
+			return err
+		}
+		r.hashes.set(GeneratedRoot, generated.Path(), hashBytes(append(generated.Source(), b...)))
+	}
+	for _, child := range root.Children() {
+		if err := r.hashGenerated(child); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// nodeProps returns the attributes of node keyed by namespace, as passed to
+// templates.
+func nodeProps(node tree.Node) (map[string]any, error) {
+	nodeAttrs, err := node.Attributes()
+	if err != nil {
+		return nil, err
+	}
+
+	props := make(map[string]any)
+	for k, v := range nodeAttrs {
+		props[k] = v
+	}
+	return props, nil
+}
@@ -0,0 +1,207 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/connormckelvey/sgunk/tree"
+)
+
+const siteDataName = "site"
+
+// WithSiteConfig exposes config to every template as site.config.
+func WithSiteConfig(config map[string]any) RendererOptionFunc {
+	return func(r *Renderer) error {
+		r.siteConfig = config
+		return nil
+	}
+}
+
+// siteData is the site object passed to every template. Pages are listed
+// in tree order, each with its kind, path, url and attributes.
+type siteData struct {
+	config map[string]any
+	pages  []map[string]any
+	hash   string
+}
+
+func newSiteData(site *tree.Site, config map[string]any) (*siteData, error) {
+	data := &siteData{
+		config: config,
+		pages:  []map[string]any{},
+	}
+	if err := data.collect(site); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(struct {
+		Config map[string]any   `json:"config"`
+		Pages  []map[string]any `json:"pages"`
+	}{data.config, data.pages})
+	if err != nil {
+		return nil, err
+	}
+	data.hash = hashBytes(b)
+	return data, nil
+}
+
+func (d *siteData) collect(node tree.Node) error {
+	if page, ok := node.(tree.Page); ok {
+		props, err := nodeProps(page)
+		if err != nil {
+			return err
+		}
+		props["kind"] = page.Kind().String()
+		props["path"] = page.Path()
+		props["url"] = page.URL()
+		d.pages = append(d.pages, props)
+	}
+	for _, child := range node.Children() {
+		if err := d.collect(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// props returns the site object for a single template run. Templates can
+// change the objects they are given, so every run gets copies of its own.
+func (d *siteData) props() map[string]any {
+	return map[string]any{
+		"config": cloneValue(d.config),
+		"pages":  cloneValue(d.pages),
+		"query":  d.query,
+	}
+}
+
+// query returns the pages matching opts, which may set:
+//
+//	kind:  only pages of this kind
+//	sort:  the dotted path of the attribute to sort by, e.g. "post.createdAt"
+//	desc:  sort in descending order
+//	limit: the maximum number of pages returned
+func (d *siteData) query(opts map[string]any) ([]map[string]any, error) {
+	kind, _ := opts["kind"].(string)
+	sortBy, _ := opts["sort"].(string)
+	desc, _ := opts["desc"].(bool)
+	limit, err := toInt(opts["limit"])
+	if err != nil {
+		return nil, fmt.Errorf("query limit: %w", err)
+	}
+
+	var pages []map[string]any
+	for _, page := range d.pages {
+		if kind != "" && page["kind"] != kind {
+			continue
+		}
+		pages = append(pages, page)
+	}
+	if sortBy != "" {
+		sort.SliceStable(pages, func(i, j int) bool {
+			a, b := lookup(pages[i], sortBy), lookup(pages[j], sortBy)
+			if desc {
+				return less(b, a)
+			}
+			return less(a, b)
+		})
+	}
+	if limit > 0 && limit < len(pages) {
+		pages = pages[:limit]
+	}
+	return cloneValue(pages).([]map[string]any), nil
+}
+
+// cloneValue returns a deep copy of the maps and slices in v. Other values
+// are returned as they are.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return v
+		}
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = cloneValue(value)
+		}
+		return m
+	case []map[string]any:
+		if v == nil {
+			return v
+		}
+		s := make([]map[string]any, len(v))
+		for i, value := range v {
+			s[i] = cloneValue(value).(map[string]any)
+		}
+		return s
+	case []any:
+		if v == nil {
+			return v
+		}
+		s := make([]any, len(v))
+		for i, value := range v {
+			s[i] = cloneValue(value)
+		}
+		return s
+	}
+	return v
+}
+
+func toInt(v any) (int, error) {
+	switch n := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
+
+func lookup(m map[string]any, key string) any {
+	var v any = m
+	for _, part := range strings.Split(key, ".") {
+		mm, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = mm[part]
+	}
+	return v
+}
+
+// less orders numbers, times and strings. Missing values sort first.
+func less(a, b any) bool {
+	if b == nil {
+		return false
+	}
+	switch a := a.(type) {
+	case nil:
+		return true
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Before(b)
+		}
+	case int:
+		if b, ok := b.(int); ok {
+			return a < b
+		}
+	case int64:
+		if b, ok := b.(int64); ok {
+			return a < b
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a < b
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return a < b
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
@@ -0,0 +1,87 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSiteDataQuery(t *testing.T) {
+	data := &siteData{pages: []map[string]any{
+		{"kind": "site", "url": "/"},
+		{"kind": "blog", "url": "/b/", "post": map[string]any{"createdAt": "2024-04-02"}},
+		{"kind": "blog", "url": "/a/", "post": map[string]any{"createdAt": "2024-04-01"}},
+		{"kind": "blog", "url": "/c/", "post": map[string]any{"createdAt": "2024-04-03"}},
+	}}
+
+	urls := func(pages []map[string]any) []any {
+		var urls []any
+		for _, page := range pages {
+			urls = append(urls, page["url"])
+		}
+		return urls
+	}
+
+	pages, err := data.query(map[string]any{"kind": "blog", "sort": "post.createdAt"})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"/a/", "/b/", "/c/"}, urls(pages))
+
+	pages, err = data.query(map[string]any{"kind": "blog", "sort": "post.createdAt", "desc": true, "limit": int64(2)})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"/c/", "/b/"}, urls(pages))
+
+	pages, err = data.query(map[string]any{"sort": "post.createdAt"})
+	require.NoError(t, err)
+	assert.Equal(t, "/", pages[0]["url"])
+
+	_, err = data.query(map[string]any{"limit": "two"})
+	assert.Error(t, err)
+}
+
+// renderPerPage renders 20 pages with body in parallel and returns the
+// build FS.
+func renderPerPage(t *testing.T, body string, opts ...RendererOption) afero.Fs {
+	t.Helper()
+	memFS := func() afero.Fs { return afero.NewBasePathFs(afero.NewMemMapFs(), "/") }
+	siteFS, themeFS, buildFS := memFS(), memFS(), memFS()
+	require.NoError(t, afero.WriteFile(themeFS, "main.html", []byte("<% $outlet %>"), 0644))
+	for i := 0; i < 20; i++ {
+		src := "---\npage:\n  template: main.html\n---\n\n" + body + "\n"
+		require.NoError(t, afero.WriteFile(siteFS, fmt.Sprintf("page%d.md", i), []byte(src), 0644))
+	}
+
+	site, err := parser.New(
+		parser.WithSiteFS(siteFS),
+		parser.WithEntryParsers(&parser.DefaultParser{}),
+	).Parse(context.Background())
+	require.NoError(t, err)
+
+	opts = append([]RendererOption{
+		WithFS(siteFS, themeFS, buildFS),
+		WithEntryRenderers(&DefaultRenderer{}),
+		WithParallelism(8),
+	}, opts...)
+	require.NoError(t, New(opts...).Render(context.Background(), site))
+	return buildFS
+}
+
+// TestSiteDataPerPage renders pages in parallel that all change the site
+// object. Each page must only see its own changes; run with -race to catch
+// pages sharing it.
+func TestSiteDataPerPage(t *testing.T) {
+	buildFS := renderPerPage(t,
+		"<% site.pages[0].seen = (site.pages[0].seen || '') + 'x' %> "+
+			"<% site.config.seen = (site.config.seen || '') + 'x' %>",
+		WithSiteConfig(map[string]any{"title": "Test"}),
+	)
+	for i := 0; i < 20; i++ {
+		b, err := afero.ReadFile(buildFS, fmt.Sprintf("page%d.html", i))
+		require.NoError(t, err)
+		assert.Equal(t, "<p>x x</p>\n", string(b), "page%d", i)
+	}
+}
@@ -0,0 +1,44 @@
+package renderer
+
+import (
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// copyThemeStatic mirrors the theme's static directory into the build root.
+// Files the site provides itself were left out of the manifest, so they
+// take priority.
+func (r *Renderer) copyThemeStatic() error {
+	for _, entry := range r.manifest.entries {
+		if entry.root != ThemeRoot {
+			continue
+		}
+		if err := r.buildFS.MkdirAll(filepath.Dir(entry.output), 0755); err != nil {
+			return err
+		}
+		if err := copyFile(r.themeFS, entry.source, r.buildFS, entry.output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(srcFS afero.Fs, srcPath string, dstFS afero.Fs, dstPath string) error {
+	src, err := srcFS.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := dstFS.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
@@ -2,64 +2,171 @@ package renderer
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"io"
 	"io/fs"
+	"maps"
+	"path"
 	"path/filepath"
 
+	"github.com/connormckelvey/sgunk/diag"
 	"github.com/connormckelvey/tmplrun/ast"
 	"github.com/connormckelvey/tmplrun/evaluator"
-	"github.com/connormckelvey/tmplrun/evaluator/driver"
 	"github.com/connormckelvey/tmplrun/lexer"
 	"github.com/connormckelvey/tmplrun/parser"
 )
 
 type Templater struct {
 	fs fs.FS
+
+	// root names fs in the dependency graph. Files read from a templater
+	// without a root are not recorded.
+	root string
+	// helpers returns the functions exposed to every template, in
+	// addition to props. It receives the dependency recorder of the
+	// current render so helpers can record what they read.
+	helpers func(deps *depRecorder) map[string]any
+	// scan is called with every template rendered, so dependencies on
+	// helpers can be recorded in deps.
+	scan func(doc *ast.Document, deps *depRecorder)
 }
 
 func NewTemplater(fsys fs.FS) *Templater {
-	return &Templater{fsys}
+	return &Templater{fs: fsys}
+}
+
+// Render templates source with props into w. Scripts still running when
+// ctx is done are interrupted.
+func (ev *Templater) Render(ctx context.Context, source io.Reader, currentFile string, props map[string]any, w io.Writer) error {
+	return ev.renderTracked(ctx, source, currentFile, props, w, nil)
 }
 
-func (ev *Templater) Render(source io.Reader, currentFile string, props map[string]any, w io.Writer) error {
-	doc, err := ev.parse(source)
+// renderTracked renders like Render, recording every file read through the
+// include and template hooks in deps.
+func (ev *Templater) renderTracked(ctx context.Context, source io.Reader, currentFile string, props map[string]any, w io.Writer, deps *depRecorder) error {
+	src, err := io.ReadAll(source)
 	if err != nil {
 		return err
 	}
-	err = ev.render(w, currentFile, doc, props)
+	doc, err := ev.parseTracked(src, deps)
+	if err != nil {
+		return err
+	}
+	err = ev.render(ctx, w, currentFile, src, doc, props, deps)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (tr *Templater) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(tr.fs, name)
+}
+
+func (tr *Templater) parseTracked(src []byte, deps *depRecorder) (*ast.Document, error) {
+	doc, err := tr.parse(bytes.NewReader(src))
+	if err != nil {
+		var lexErr lexer.Error
+		if errors.As(err, &lexErr) {
+			line, column := diag.Position(src, lexErr.Pos)
+			return nil, &diag.PosError{Line: line, Column: column, Err: err}
+		}
+		return nil, err
+	}
+	if tr.scan != nil {
+		tr.scan(doc, deps)
+	}
+	return doc, nil
+}
+
 func (tr *Templater) parse(r io.Reader) (*ast.Document, error) {
 	lex := lexer.New(r)
 	par := parser.New(lex)
 	return par.Parse()
 }
 
-func (tr *Templater) render(w io.Writer, currentFile string, doc *ast.Document, props map[string]any) error {
+// render evaluates the top level nodes of doc, parsed from src, one at a
+// time, so errors can be located at the tag that raised them.
+func (tr *Templater) render(ctx context.Context, w io.Writer, currentFile string, src []byte, doc *ast.Document, props map[string]any, deps *depRecorder) error {
+	if tr.helpers != nil {
+		env := tr.helpers(deps)
+		maps.Copy(env, props)
+		props = env
+	}
 	hooks := &hooks{
+		ctx:         ctx,
 		tr:          tr,
 		currentFile: currentFile,
+		deps:        deps,
 	}
-	ev := evaluator.New(driver.NewGoja(), hooks)
-	res, err := ev.Render(doc, evaluator.NewEnvironment(tr.fs, props, hooks))
-	if err != nil {
-		return err
+	ev := evaluator.New(newGojaDriver(ctx), hooks)
+	env := evaluator.NewEnvironment(tr.fs, props, hooks)
+	offset := 0
+	for _, node := range doc.Children() {
+		var single ast.Document
+		single.Append(node)
+		res, err := ev.Render(&single, env)
+		if err != nil {
+			return locate(err, src, offset)
+		}
+		offset += sourceLen(node)
+		if _, err := io.WriteString(w, res); err != nil {
+			return err
+		}
 	}
-	_, err = w.Write([]byte(res))
-	if err != nil {
+	return nil
+}
+
+// locate returns err raised by the node at offset in src at the position of
+// the node, unless err is already located, e.g. in a partial.
+func locate(err error, src []byte, offset int) error {
+	var pe *diag.PosError
+	if errors.As(err, &pe) {
 		return err
 	}
+	line, column := diag.Position(src, offset)
+	return &diag.PosError{Line: line, Column: column, Err: err}
+}
 
-	return nil
+// sourceLen returns the length of the source node was parsed from. tmplrun
+// tokens carry no position, so positions are recovered by adding up the
+// lengths of the nodes before.
+func sourceLen(node ast.Node) int {
+	switch n := node.(type) {
+	case *ast.TextNode:
+		return len(n.Token.Literal)
+	case *ast.TemplateNode:
+		length := len(n.Token.Literal)
+		for _, child := range n.Children() {
+			length += sourceLen(child)
+		}
+		if n.Closed {
+			// The close tag mirrors the open tag, e.g. <%%js and js%%>.
+			m := lexer.OpenTagPattern.FindStringSubmatch(n.Token.Literal)
+			length += len(m[3]) + len(m[2]) + len(">")
+		}
+		return length
+	}
+	return 0
 }
 
 type hooks struct {
+	ctx         context.Context
 	tr          *Templater
 	currentFile string
+	deps        *depRecorder
+}
+
+func (th *hooks) readFile(name string) ([]byte, error) {
+	b, err := th.tr.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if th.tr.root != "" {
+		th.deps.record(th.tr.root, name, b)
+	}
+	return b, nil
 }
 
 func (th *hooks) resolve(name string) string {
@@ -69,7 +176,7 @@ func (th *hooks) resolve(name string) string {
 
 func (th *hooks) Include(name string) (string, error) {
 	rel := th.resolve(name)
-	b, err := fs.ReadFile(th.tr.fs, rel)
+	b, err := th.readFile(rel)
 	if err != nil {
 		return "", err
 	}
@@ -78,20 +185,18 @@ func (th *hooks) Include(name string) (string, error) {
 
 func (th *hooks) Render(name string, props map[string]any) (string, error) {
 	rel := th.resolve(name)
-	src, err := fs.ReadFile(th.tr.fs, rel)
+	src, err := th.readFile(rel)
 	if err != nil {
 		return "", err
 	}
 
-	doc, err := th.tr.parse(bytes.NewReader(src))
-	if err != nil {
-		return "", err
+	doc, err := th.tr.parseTracked(src, th.deps)
+	if err == nil {
+		var buf bytes.Buffer
+		err = th.tr.render(th.ctx, &buf, rel, src, doc, props, th.deps)
+		if err == nil {
+			return buf.String(), nil
+		}
 	}
-	var buf bytes.Buffer
-	err = th.tr.render(&buf, rel, doc, props)
-	if err != nil {
-		return "", err
-	}
-
-	return buf.String(), nil
+	return "", diag.At(err, path.Join(th.tr.root, filepath.ToSlash(rel)), 0)
 }
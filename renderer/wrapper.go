@@ -2,39 +2,47 @@ package renderer
 
 import (
 	"bytes"
+	"context"
 	"maps"
+	"path"
 
 	"github.com/adrg/frontmatter"
+	"github.com/connormckelvey/sgunk/diag"
 	"github.com/spf13/afero"
 )
 
-func WrapTheme(themeFs afero.Fs, themeFile string, content []byte, props map[string]any) ([]byte, error) {
-	file, err := themeFs.Open(themeFile)
+func WrapTheme(ctx context.Context, themeFs afero.Fs, themeFile string, content []byte, props map[string]any) ([]byte, error) {
+	return wrapTheme(ctx, NewTemplater(afero.NewIOFS(themeFs)), themeFile, content, props, nil)
+}
+
+// wrapTheme wraps content like WrapTheme, rendering through tr and recording
+// every theme file read along the template chain in deps.
+func wrapTheme(ctx context.Context, tr *Templater, themeFile string, content []byte, props map[string]any, deps *depRecorder) ([]byte, error) {
+	source, err := tr.readFile(themeFile)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	deps.record(ThemeRoot, themeFile, source)
 
 	var fm struct {
 		Template string `yaml:"template"`
 	}
-	theme, err := frontmatter.Parse(file, &fm)
+	file := path.Join(tr.root, themeFile)
+	theme, err := frontmatter.Parse(bytes.NewReader(source), &fm)
 	if err != nil {
-		return nil, err
+		return nil, diag.At(diag.FrontMatter(err), file, 0)
 	}
 
-	ev := NewTemplater(afero.NewIOFS(themeFs))
-
 	var w bytes.Buffer
 	newProps := maps.Clone(props)
 	newProps["$outlet"] = string(content)
-	if err := ev.Render(bytes.NewReader(theme), "", newProps, &w); err != nil {
-		return nil, err
+	if err := tr.renderTracked(ctx, bytes.NewReader(theme), "", newProps, &w, deps); err != nil {
+		return nil, diag.At(err, file, frontMatterLines(source, theme))
 	}
 
 	if fm.Template == "" {
 		return w.Bytes(), nil
 	}
 
-	return WrapTheme(themeFs, fm.Template, w.Bytes(), props)
+	return wrapTheme(ctx, tr, fm.Template, w.Bytes(), props, deps)
 }
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const reloadPath = "/_sgunk/reload"
+
+var reloadScript = []byte(`<script>new EventSource("` + reloadPath + `").onmessage = function () { location.reload(); };</script>`)
+
+// injectReloadScript inserts the live reload client before the closing body
+// tag, or appends it when the document has none.
+func injectReloadScript(html []byte) []byte {
+	i := bytes.LastIndex(html, []byte("</body>"))
+	if i < 0 {
+		return append(html, reloadScript...)
+	}
+	out := make([]byte, 0, len(html)+len(reloadScript))
+	out = append(out, html[:i]...)
+	out = append(out, reloadScript...)
+	return append(out, html[i:]...)
+}
+
+// broker fans reload events out to every connected server-sent events client.
+type broker struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{
+		clients: make(map[chan struct{}]struct{}),
+	}
+}
+
+func (b *broker) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan struct{}) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+func (b *broker) publish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (b *broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		}
+	}
+}
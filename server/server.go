@@ -0,0 +1,314 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/diag"
+	"github.com/spf13/afero"
+)
+
+const (
+	defaultAddr     = "localhost:8080"
+	defaultInterval = 500 * time.Millisecond
+)
+
+// Server builds a project, serves its build directory over HTTP and
+// rebuilds it whenever the site, theme or config files change. Open pages
+// are reloaded through a server-sent events endpoint once a rebuild
+// succeeds.
+type Server struct {
+	options  []ServerOption
+	project  *sgunk.Project
+	addr     string
+	interval time.Duration
+	broker   *broker
+	watcher  *Watcher
+	mu       sync.Mutex
+
+	// buildFS holds a copy of the output of the last successful build,
+	// served by ServeHTTP while the next build replaces the build
+	// directory.
+	buildMu sync.RWMutex
+	buildFS afero.Fs
+
+	// cancelBuild cancels the build started by the last rebuild.
+	cancelMu    sync.Mutex
+	cancelBuild context.CancelFunc
+	builds      sync.WaitGroup
+}
+
+type ServerOption interface {
+	Apply(*Server) error
+}
+
+type ServerOptionFunc func(*Server) error
+
+func (apply ServerOptionFunc) Apply(s *Server) error {
+	return apply(s)
+}
+
+func WithAddr(addr string) ServerOptionFunc {
+	return func(s *Server) error {
+		s.addr = addr
+		return nil
+	}
+}
+
+func WithInterval(interval time.Duration) ServerOptionFunc {
+	return func(s *Server) error {
+		if interval <= 0 {
+			return errors.New("watch interval must be positive")
+		}
+		s.interval = interval
+		return nil
+	}
+}
+
+func New(project *sgunk.Project, opts ...ServerOption) *Server {
+	return &Server{
+		options:  opts,
+		project:  project,
+		addr:     defaultAddr,
+		interval: defaultInterval,
+		broker:   newBroker(),
+	}
+}
+
+func (s *Server) build(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	err := s.project.Generate(ctx)
+	for _, skipped := range s.project.Skipped() {
+		log.Printf("skipped %s: %s", skipped.Path, skipped.Reason)
+	}
+	if diags := s.project.Diagnostics(); len(diags) > 0 && ctx.Err() == nil {
+		if err := diag.Report(log.Writer(), diags); err != nil {
+			return err
+		}
+	}
+	var list diag.List
+	if err == nil || errors.As(err, &list) {
+		// The project is configured, and the config may have moved the
+		// directories to watch.
+		s.updateWatchPaths()
+	}
+	if errors.As(err, &list) {
+		return errors.New("site has errors")
+	}
+	if err != nil {
+		return err
+	}
+	buildFS, err := snapshot(s.project.BuildFS())
+	if err != nil {
+		return err
+	}
+	s.buildMu.Lock()
+	s.buildFS = buildFS
+	s.buildMu.Unlock()
+	stats := s.project.RenderStats()
+	log.Printf("built %s in %s (%d rendered, %d reused)", s.project.BuildDir(), time.Since(start).Round(time.Millisecond), stats.Rendered, stats.Reused)
+	return nil
+}
+
+// snapshot copies the files in fsys into memory.
+func snapshot(fsys afero.Fs) (afero.Fs, error) {
+	memFS := afero.NewBasePathFs(afero.NewMemMapFs(), "/")
+	err := afero.Walk(fsys, "/", func(name string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return memFS.MkdirAll(name, 0755)
+		}
+		b, err := afero.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if err := afero.WriteFile(memFS, name, b, info.Mode()); err != nil {
+			return err
+		}
+		return memFS.Chtimes(name, info.ModTime(), info.ModTime())
+	})
+	return memFS, err
+}
+
+// rebuild cancels the build in progress, if any, and starts a new one, so
+// a burst of changes is built once, from the latest state.
+func (s *Server) rebuild(ctx context.Context) {
+	s.cancelMu.Lock()
+	defer s.cancelMu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	if s.cancelBuild != nil {
+		s.cancelBuild()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancelBuild = cancel
+
+	s.builds.Add(1)
+	go func() {
+		defer s.builds.Done()
+		defer cancel()
+		err := s.build(ctx)
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Printf("build canceled")
+		case err != nil:
+			log.Printf("build failed: %v", err)
+		default:
+			s.broker.publish()
+		}
+	}()
+}
+
+func (s *Server) watchPaths() []string {
+	paths := []string{
+		s.project.SiteDir(),
+		s.project.DataDir(),
+	}
+	themeDirs, err := s.project.ThemeDirs()
+	if err != nil {
+		// The build reports the error; watch the theme dir so fixing it
+		// triggers a rebuild.
+		themeDirs = []string{s.project.ThemeDir()}
+	}
+	paths = append(paths, themeDirs...)
+	return append(paths, s.project.ConfigFiles()...)
+}
+
+func (s *Server) updateWatchPaths() {
+	if s.watcher == nil {
+		return
+	}
+	if err := s.watcher.SetPaths(s.watchPaths()...); err != nil {
+		log.Printf("watch: %v", err)
+	}
+}
+
+// ListenAndServe builds the project once, then serves it and watches for
+// changes until ctx is done.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	for _, opt := range s.options {
+		if err := opt.Apply(s); err != nil {
+			return err
+		}
+	}
+
+	if err := s.build(ctx); err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(reloadPath, s.broker)
+	mux.Handle("/", s)
+
+	srv := &http.Server{
+		Addr:    s.addr,
+		Handler: mux,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.watcher = NewWatcher(s.project.RootFS(), s.interval, s.watchPaths()...)
+	errs := make(chan error, 2)
+	go func() {
+		errs <- s.watcher.Watch(ctx, func() {
+			s.rebuild(ctx)
+		})
+	}()
+	go func() {
+		log.Printf("serving %s on http://%s", s.project.BuildDir(), s.addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+	}
+
+	// Once cancelMu is released, rebuild sees ctx is done and starts no
+	// more builds.
+	cancel()
+	s.cancelMu.Lock()
+	s.cancelMu.Unlock()
+	s.builds.Wait()
+
+	shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	if shutdownErr := srv.Shutdown(shutdownCtx); err == nil {
+		err = shutdownErr
+	}
+	if errors.Is(err, http.ErrServerClosed) || errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// resolve maps a request path to a file in buildFS. Directories resolve to
+// their index.html and extensionless paths to the matching .html page,
+// mirroring how most static hosts serve the build output.
+func resolve(buildFS afero.Fs, urlPath string) (string, fs.FileInfo, bool) {
+	name := filepath.FromSlash(path.Clean("/" + urlPath))
+	candidates := []string{name}
+	if path.Ext(urlPath) == "" {
+		candidates = append(candidates,
+			filepath.Join(name, "index.html"),
+			name+".html",
+		)
+	}
+	for _, candidate := range candidates {
+		info, err := buildFS.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, info, true
+		}
+	}
+	return "", nil, false
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.buildMu.RLock()
+	buildFS := s.buildFS
+	s.buildMu.RUnlock()
+	if buildFS == nil {
+		http.NotFound(w, r)
+		return
+	}
+	name, info, ok := resolve(buildFS, r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if !strings.EqualFold(filepath.Ext(name), ".html") {
+		f, err := buildFS.Open(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		http.ServeContent(w, r, name, info.ModTime(), f)
+		return
+	}
+	b, err := afero.ReadFile(buildFS, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(injectReloadScript(b))
+}
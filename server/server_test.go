@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/sgunktest"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fixture = `
+-- project.yml --
+version: v0.1
+name: Example
+-- site/index.md --
+---
+page:
+  title: Home
+---
+
+# <% page.title %>
+-- site/docs/intro.md --
+Intro
+-- site/style.css --
+body { margin: 0; }
+`
+
+// A project read from and built into memory is served and watched without
+// touching the disk.
+func TestServeInMemory(t *testing.T) {
+	rootFS := sgunktest.NewFS(t, sgunktest.ParseArchive([]byte(fixture)))
+	project := sgunk.New(
+		sgunk.WithRootFS(rootFS),
+		sgunk.WithWorkDir("/"),
+		sgunk.WithOutputFS(afero.NewBasePathFs(afero.NewMemMapFs(), "/")),
+	)
+	s := New(project)
+	require.NoError(t, s.build(context.Background()))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+	w := get("/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<h1>Home</h1>")
+	assert.Contains(t, w.Body.String(), reloadPath)
+	assert.Equal(t, http.StatusOK, get("/docs/intro").Code)
+	w = get("/style.css")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "body { margin: 0; }\n", w.Body.String())
+	assert.Equal(t, http.StatusNotFound, get("/missing").Code)
+
+	watcher := NewWatcher(project.RootFS(), time.Millisecond, s.watchPaths()...)
+	snapshot, err := watcher.scan()
+	require.NoError(t, err)
+	assert.Contains(t, snapshot, "/site/index.md")
+	watcher.snapshot = snapshot
+	require.NoError(t, afero.WriteFile(rootFS, "/site/new.md", []byte("New"), 0644))
+	next, err := watcher.scan()
+	require.NoError(t, err)
+	assert.True(t, watcher.changed(next))
+}
+
+// Moving the site directory in the config moves the watch with it.
+func TestWatchPathsFollowConfig(t *testing.T) {
+	rootFS := sgunktest.NewFS(t, sgunktest.ParseArchive([]byte(fixture)))
+	project := sgunk.New(
+		sgunk.WithRootFS(rootFS),
+		sgunk.WithWorkDir("/"),
+		sgunk.WithOutputFS(afero.NewBasePathFs(afero.NewMemMapFs(), "/")),
+	)
+	s := New(project)
+	require.NoError(t, s.build(context.Background()))
+	s.watcher = NewWatcher(project.RootFS(), time.Millisecond, s.watchPaths()...)
+	assert.Contains(t, s.watcher.paths, "/site")
+
+	require.NoError(t, afero.WriteFile(rootFS, "/pages/index.md", []byte("Moved"), 0644))
+	require.NoError(t, afero.WriteFile(rootFS, "/project.yml", []byte("name: Example\nsite:\n  dir: pages\n"), 0644))
+	require.NoError(t, s.build(context.Background()))
+	assert.Contains(t, s.watcher.paths, "/pages")
+	assert.NotContains(t, s.watcher.paths, "/site")
+
+	changed, err := s.watcher.poll()
+	require.NoError(t, err)
+	assert.False(t, changed)
+	require.NoError(t, afero.WriteFile(rootFS, "/pages/index.md", []byte("Edited"), 0644))
+	changed, err = s.watcher.poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+}
+
+// Pages are served from the last successful build while the next one
+// rewrites the build directory.
+func TestServeDuringRebuild(t *testing.T) {
+	rootFS := sgunktest.NewFS(t, sgunktest.ParseArchive([]byte(fixture)))
+	var block atomic.Bool
+	started, release := make(chan struct{}), make(chan struct{})
+	project := sgunk.New(
+		sgunk.WithRootFS(rootFS),
+		sgunk.WithWorkDir("/"),
+		sgunk.WithOutputFS(afero.NewBasePathFs(afero.NewMemMapFs(), "/")),
+		sgunk.WithRendererOptions(renderer.WithTemplateHelper("pause", func() string {
+			if block.CompareAndSwap(true, false) {
+				close(started)
+				<-release
+			}
+			return ""
+		})),
+	)
+	s := New(project)
+	require.NoError(t, afero.WriteFile(rootFS, "/site/docs/intro.md", []byte("Intro<% pause() %>"), 0644))
+	require.NoError(t, s.build(context.Background()))
+
+	get := func(path string) string {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, w.Code, path)
+		return w.Body.String()
+	}
+
+	block.Store(true)
+	require.NoError(t, afero.WriteFile(rootFS, "/site/index.md", []byte("# Rebuilt"), 0644))
+	done := make(chan error)
+	go func() { done <- s.build(context.Background()) }()
+	<-started
+	assert.Contains(t, get("/"), "<h1>Home</h1>")
+	assert.Contains(t, get("/docs/intro"), "Intro")
+	close(release)
+	require.NoError(t, <-done)
+	assert.Contains(t, get("/"), "<h1>Rebuilt</h1>")
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher polls a set of files and directories in fsys and reports when
+// any of them are created, removed or modified.
+type Watcher struct {
+	fsys     afero.Fs
+	mu       sync.Mutex
+	paths    []string
+	interval time.Duration
+	snapshot map[string]fileStamp
+}
+
+func NewWatcher(fsys afero.Fs, interval time.Duration, paths ...string) *Watcher {
+	return &Watcher{
+		fsys:     fsys,
+		paths:    paths,
+		interval: interval,
+	}
+}
+
+// SetPaths replaces the watched paths, e.g. after a config change moved the
+// site directory. The files under them are the new baseline, so switching
+// paths is not reported as a change.
+func (w *Watcher) SetPaths(paths ...string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if slices.Equal(paths, w.paths) {
+		return nil
+	}
+	w.paths = paths
+	snapshot, err := w.scan()
+	if err != nil {
+		return err
+	}
+	w.snapshot = snapshot
+	return nil
+}
+
+// poll scans the watched paths and reports whether they changed since the
+// last poll.
+func (w *Watcher) poll() (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	next, err := w.scan()
+	if err != nil {
+		return false, err
+	}
+	if !w.changed(next) {
+		return false, nil
+	}
+	w.snapshot = next
+	return true, nil
+}
+
+func (w *Watcher) scan() (map[string]fileStamp, error) {
+	snapshot := make(map[string]fileStamp)
+	for _, root := range w.paths {
+		err := afero.Walk(w.fsys, root, func(path string, info fs.FileInfo, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			snapshot[path] = fileStamp{
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+func (w *Watcher) changed(next map[string]fileStamp) bool {
+	if len(next) != len(w.snapshot) {
+		return true
+	}
+	for path, stamp := range next {
+		prev, ok := w.snapshot[path]
+		if !ok || !prev.modTime.Equal(stamp.modTime) || prev.size != stamp.size {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch calls onChange every time a change is detected, until ctx is done.
+func (w *Watcher) Watch(ctx context.Context, onChange func()) error {
+	w.mu.Lock()
+	snapshot, err := w.scan()
+	if err == nil {
+		w.snapshot = snapshot
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			changed, err := w.poll()
+			if err != nil {
+				return err
+			}
+			if changed {
+				onChange()
+			}
+		}
+	}
+}
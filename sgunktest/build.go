@@ -0,0 +1,89 @@
+package sgunktest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/tree"
+	"github.com/spf13/afero"
+)
+
+// Build generates the project in fixture in memory. The output is found at
+// the returned project's BuildFS and the parsed site at its Site. Options
+// are applied after the project config is loaded, so extensions and
+// overrides such as sgunk.WithDrafts can be passed.
+func Build(t testing.TB, fixture fs.FS, opts ...sgunk.ProjectOption) *sgunk.Project {
+	t.Helper()
+	opts = append([]sgunk.ProjectOption{
+		sgunk.WithRootFS(NewFS(t, fixture)),
+		sgunk.WithWorkDir("/"),
+	}, opts...)
+	project := sgunk.New(opts...)
+	if err := project.Generate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return project
+}
+
+// Parse parses the site directory of fixture with the built-in entry
+// parsers, after any added by opts, so an extension's parser can be tested
+// without the rest of the project.
+func Parse(t testing.TB, fixture fs.FS, opts ...parser.ParserOption) *tree.Site {
+	t.Helper()
+	fsys := NewFS(t, fixture)
+	opts = append([]parser.ParserOption{
+		parser.WithSiteFS(afero.NewBasePathFs(fsys, "/site")),
+	}, opts...)
+	opts = append(opts, parser.WithEntryParsers(
+		parser.NewAssetParser(),
+		&parser.DefaultParser{},
+	))
+	site, err := parser.New(opts...).Parse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return site
+}
+
+// Render renders site with the site, theme and data directories of
+// fixture, the config of its project.yml if it has one, and the built-in
+// entry renderers, returning the output. opts can add the entry renderers
+// of an extension.
+func Render(t testing.TB, site *tree.Site, fixture fs.FS, opts ...renderer.RendererOption) afero.Fs {
+	t.Helper()
+	fsys := NewFS(t, fixture)
+	siteConfig := map[string]any{}
+	config, err := sgunk.LoadConfigFile(fsys)
+	switch {
+	case err == nil:
+		if siteConfig, err = config.Map(); err != nil {
+			t.Fatal(err)
+		}
+	case !errors.Is(err, sgunk.ErrConfigNotFound):
+		t.Fatal(err)
+	}
+
+	buildFS := afero.NewMemMapFs()
+	opts = append([]renderer.RendererOption{
+		renderer.WithFS(
+			afero.NewBasePathFs(fsys, "/site"),
+			afero.NewReadOnlyFs(afero.NewBasePathFs(fsys, "/theme")),
+			buildFS,
+		),
+		renderer.WithDataFS(afero.NewBasePathFs(fsys, "/data")),
+		renderer.WithSiteConfig(siteConfig),
+		renderer.WithEntryRenderers(
+			&renderer.DefaultRenderer{},
+			&renderer.AssetRenderer{},
+		),
+	}, opts...)
+	if err := renderer.New(opts...).Render(context.Background(), site); err != nil {
+		t.Fatal(err)
+	}
+	return buildFS
+}
@@ -0,0 +1,100 @@
+// Package sgunktest builds sgunk projects in memory, so sites and
+// extensions can be tested against golden files without touching disk.
+//
+// Fixtures are file systems laid out like a project: a project.yml with the
+// site, theme and data directories next to it. They can be written inline
+// as an fstest.MapFS or kept in a txtar archive:
+//
+//	-- project.yml --
+//	version: v0.1
+//	-- site/index.md --
+//	---
+//	page:
+//	  template: main.html
+//	---
+//	# Hello
+//	-- theme/main.html --
+//	<main><% $outlet %></main>
+package sgunktest
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/spf13/afero"
+	"golang.org/x/tools/txtar"
+)
+
+// ParseArchive returns the files of a txtar archive.
+func ParseArchive(data []byte) fstest.MapFS {
+	fixture := make(fstest.MapFS)
+	for _, file := range txtar.Parse(data).Files {
+		fixture[file.Name] = &fstest.MapFile{Data: file.Data, Mode: 0644}
+	}
+	return fixture
+}
+
+// ReadArchive reads the files of the txtar archive at name.
+func ReadArchive(t testing.TB, name string) fstest.MapFS {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseArchive(data)
+}
+
+// ReadDir reads the files under dir into memory. Modification times are
+// dropped, so builds of the fixture are reproducible.
+func ReadDir(t testing.TB, dir string) fstest.MapFS {
+	t.Helper()
+	fixture := make(fstest.MapFS)
+	err := fs.WalkDir(os.DirFS(dir), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		fixture[name] = &fstest.MapFile{Data: data, Mode: 0644}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fixture
+}
+
+// NewFS copies fixture to the root of an in-memory file system, keeping
+// the modification time of every file.
+func NewFS(t testing.TB, fixture fs.FS) afero.Fs {
+	t.Helper()
+	// MemMapFs tells relative names from absolute ones, BasePathFs makes
+	// every name absolute.
+	fsys := afero.NewBasePathFs(afero.NewMemMapFs(), "/")
+	err := fs.WalkDir(fixture, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fixture, name)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := afero.WriteFile(fsys, name, data, 0644); err != nil {
+			return err
+		}
+		return fsys.Chtimes(name, info.ModTime(), info.ModTime())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fsys
+}
@@ -0,0 +1,86 @@
+package sgunktest
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/txtar"
+)
+
+// UpdateEnv is the environment variable that makes Golden write the golden
+// files instead of comparing them. It is read from the environment rather
+// than a flag, so test binaries are free to define flags of their own.
+const UpdateEnv = "SGUNK_UPDATE_GOLDEN"
+
+// Golden compares the files in fsys, such as the output of Build, with the
+// txtar archive at name. Running the tests with SGUNK_UPDATE_GOLDEN=1
+// writes fsys to the archive instead.
+func Golden(t testing.TB, fsys afero.Fs, name string) {
+	t.Helper()
+	got, err := archive(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if update, _ := strconv.ParseBool(os.Getenv(UpdateEnv)); update {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, txtar.Format(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("%v; run the tests with %s=1 to create it", err, UpdateEnv)
+	}
+	want := txtar.Parse(data)
+
+	if !assert.Equal(t, fileNames(want), fileNames(got), "files differ from %s; run the tests with %s=1 if the change is intended", name, UpdateEnv) {
+		return
+	}
+	for i, file := range want.Files {
+		assert.Equal(t, string(file.Data), string(got.Files[i].Data), "%s differs from %s", file.Name, name)
+	}
+}
+
+// archive returns the files in fsys in order of name. Like txtar.Format,
+// it ends every file with a newline, so a missing final newline is not
+// reported as a difference.
+func archive(fsys afero.Fs) (*txtar.Archive, error) {
+	ar := &txtar.Archive{}
+	err := afero.Walk(fsys, ".", func(name string, info fs.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := afero.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		ar.Files = append(ar.Files, txtar.File{Name: filepath.ToSlash(name), Data: data})
+		return nil
+	})
+	sort.Slice(ar.Files, func(i, j int) bool {
+		return ar.Files[i].Name < ar.Files[j].Name
+	})
+	return ar, err
+}
+
+func fileNames(ar *txtar.Archive) []string {
+	names := []string{}
+	for _, file := range ar.Files {
+		names = append(names, file.Name)
+	}
+	return names
+}
@@ -0,0 +1,29 @@
+package sgunktest_test
+
+import (
+	"testing"
+
+	"github.com/connormckelvey/sgunk/sgunktest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	fixture := sgunktest.ReadArchive(t, "testdata/site.txtar")
+	project := sgunktest.Build(t, fixture)
+	sgunktest.Golden(t, project.BuildFS(), "testdata/site.golden.txtar")
+
+	node := sgunktest.FindNode(t, project.Site(), "docs/intro.md")
+	sgunktest.AssertAttrs(t, node, "page", map[string]any{
+		"title":    "Intro",
+		"template": "main.html",
+	})
+}
+
+func TestParseAndRender(t *testing.T) {
+	fixture := sgunktest.ReadArchive(t, "testdata/site.txtar")
+	site := sgunktest.Parse(t, fixture)
+	assert.Equal(t, []string{"docs", "docs/intro.md", "index.md", "style.css"}, sgunktest.Paths(site))
+
+	buildFS := sgunktest.Render(t, site, fixture)
+	sgunktest.Golden(t, buildFS, "testdata/site.golden.txtar")
+}
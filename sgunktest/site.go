@@ -0,0 +1,63 @@
+package sgunktest
+
+import (
+	"testing"
+
+	"github.com/connormckelvey/sgunk/tree"
+	"github.com/stretchr/testify/assert"
+)
+
+// Paths returns the path of every node under root, in tree order.
+func Paths(root tree.Node) []string {
+	var paths []string
+	for _, child := range root.Children() {
+		paths = append(paths, child.Path())
+		paths = append(paths, Paths(child)...)
+	}
+	return paths
+}
+
+// FindNode returns the node under root with path, failing the test if
+// there is none.
+func FindNode(t testing.TB, root tree.Node, path string) tree.Node {
+	t.Helper()
+	if node := findNode(root, path); node != nil {
+		return node
+	}
+	t.Fatalf("no node at %s, found %v", path, Paths(root))
+	return nil
+}
+
+func findNode(root tree.Node, path string) tree.Node {
+	for _, child := range root.Children() {
+		if child.Path() == path {
+			return child
+		}
+		if node := findNode(child, path); node != nil {
+			return node
+		}
+	}
+	return nil
+}
+
+// AssertAttrs asserts that the attributes node has under key include
+// want. Attributes not in want are ignored, and values are compared after
+// conversion, so 2 matches an int64 attribute.
+func AssertAttrs(t testing.TB, node tree.Node, key string, want map[string]any) bool {
+	t.Helper()
+	got, ok := node.GetAttrs(key)
+	if !assert.True(t, ok, "%s has no %s attributes", node.Path(), key) {
+		return false
+	}
+	ok = true
+	for name, value := range want {
+		if !assert.Contains(t, got, name, "%s has no attribute %s.%s", node.Path(), key, name) {
+			ok = false
+			continue
+		}
+		if !assert.EqualValues(t, value, got[name], "attribute %s.%s of %s", key, name, node.Path()) {
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,52 @@
+package sitemap
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// RobotsRule is a group of robots.txt directives for a user agent.
+type RobotsRule struct {
+	UserAgent string
+	Allow     []string
+	Disallow  []string
+}
+
+// WriteRobots writes a robots.txt with rules that points crawlers at the
+// sitemap. Without rules every crawler is allowed everywhere. A robots.txt
+// already in fsys, e.g. copied from the site, is left alone.
+func WriteRobots(fsys afero.Fs, baseURL string, rules []RobotsRule) error {
+	base, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	if _, err := fsys.Stat(RobotsFile); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if len(rules) == 0 {
+		rules = []RobotsRule{{UserAgent: "*", Allow: []string{"/"}}}
+	}
+	var b bytes.Buffer
+	for _, rule := range rules {
+		userAgent := rule.UserAgent
+		if userAgent == "" {
+			userAgent = "*"
+		}
+		fmt.Fprintf(&b, "User-agent: %s\n", userAgent)
+		for _, p := range rule.Allow {
+			fmt.Fprintf(&b, "Allow: %s\n", p)
+		}
+		for _, p := range rule.Disallow {
+			fmt.Fprintf(&b, "Disallow: %s\n", p)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Sitemap: %s\n", absURL(base, File))
+	return afero.WriteFile(fsys, RobotsFile, b.Bytes(), 0644)
+}
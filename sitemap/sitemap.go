@@ -0,0 +1,166 @@
+// Package sitemap writes the sitemap.xml and robots.txt of a build from the
+// outputs the renderer wrote.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/spf13/afero"
+)
+
+const (
+	xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+	// File is the sitemap, or the sitemap index when the site has more
+	// URLs than fit in a single sitemap.
+	File = "sitemap.xml"
+	// RobotsFile is written at the build root unless the site has its own.
+	RobotsFile = "robots.txt"
+)
+
+// maxURLs is the number of URLs a single sitemap may list under the
+// sitemaps.org protocol.
+var maxURLs = 50000
+
+// URL is a page listed in the sitemap.
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// URLs returns the HTML pages among outputs as absolute URLs under baseURL,
+// leaving out pages whose front matter sets sitemap to false. index.html
+// pages are listed under the URL of their directory.
+func URLs(baseURL string, outputs []renderer.Output) ([]URL, error) {
+	base, err := parseBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	var urls []URL
+	for _, output := range outputs {
+		if path.Ext(output.Path) != ".html" {
+			continue
+		}
+		if output.Node != nil {
+			page, _ := output.Node.GetAttrs("page")
+			if include, ok := page["sitemap"].(bool); ok && !include {
+				continue
+			}
+		}
+		p := output.Path
+		if path.Base(p) == "index.html" {
+			p = strings.TrimSuffix(p, "index.html")
+		}
+		urls = append(urls, URL{
+			Loc:     absURL(base, p),
+			LastMod: output.LastMod,
+		})
+	}
+	return urls, nil
+}
+
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("sitemap requires baseURL in the project config")
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !base.IsAbs() {
+		return nil, fmt.Errorf("baseURL '%s' is not absolute", baseURL)
+	}
+	return base, nil
+}
+
+// absURL resolves the slash separated path p under base, keeping trailing
+// slashes, so the root page is listed as base + "/". p is escaped, so
+// paths with spaces or non-ASCII characters make valid URLs.
+func absURL(base *url.URL, p string) string {
+	escaped := (&url.URL{Path: strings.TrimPrefix(p, "/")}).EscapedPath()
+	return strings.TrimSuffix(base.String(), "/") + "/" + escaped
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []urlElement `xml:"url"`
+}
+
+type urlElement struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name         `xml:"sitemapindex"`
+	Xmlns    string           `xml:"xmlns,attr"`
+	Sitemaps []sitemapElement `xml:"sitemap"`
+}
+
+type sitemapElement struct {
+	Loc string `xml:"loc"`
+}
+
+func lastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Write writes the sitemap of urls to the root of fsys. Sites with more
+// URLs than a sitemap may list get numbered sitemaps, sitemap-1.xml and so
+// on, and File becomes an index of them.
+func Write(fsys afero.Fs, baseURL string, urls []URL) error {
+	base, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	if len(urls) <= maxURLs {
+		return writeURLSet(fsys, File, urls)
+	}
+
+	index := sitemapIndex{Xmlns: xmlns}
+	for i := 0; i*maxURLs < len(urls); i++ {
+		chunk := urls[i*maxURLs : min((i+1)*maxURLs, len(urls))]
+		name := fmt.Sprintf("sitemap-%d.xml", i+1)
+		if err := writeURLSet(fsys, name, chunk); err != nil {
+			return err
+		}
+		index.Sitemaps = append(index.Sitemaps, sitemapElement{
+			Loc: absURL(base, name),
+		})
+	}
+	return writeXML(fsys, File, index)
+}
+
+func writeURLSet(fsys afero.Fs, name string, urls []URL) error {
+	set := urlSet{Xmlns: xmlns}
+	for _, u := range urls {
+		set.URLs = append(set.URLs, urlElement{
+			Loc:     u.Loc,
+			LastMod: lastMod(u.LastMod),
+		})
+	}
+	return writeXML(fsys, name, set)
+}
+
+func writeXML(fsys afero.Fs, name string, v any) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	enc := xml.NewEncoder(&b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	b.WriteString("\n")
+	return afero.WriteFile(fsys, name, b.Bytes(), 0644)
+}
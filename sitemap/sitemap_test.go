@@ -0,0 +1,63 @@
+package sitemap
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/connormckelvey/sgunk/parser"
+	"github.com/connormckelvey/sgunk/renderer"
+	"github.com/connormckelvey/sgunk/tree"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLs(t *testing.T) {
+	hidden := tree.NewDefaultPage("private.md", tree.PageNameParts{})
+	require.NoError(t, hidden.AddAttrs("page", parser.PageAttributes{Sitemap: false}))
+	modified := time.Date(2024, time.April, 9, 0, 0, 0, 0, time.UTC)
+
+	urls, err := URLs("https://example.com/", []renderer.Output{
+		{Path: "index.html", Node: tree.NewDefaultPage("index.md", tree.PageNameParts{}), LastMod: modified},
+		{Path: "blog/index.html"},
+		{Path: "about.html"},
+		{Path: "notes/my notes.html"},
+		{Path: "café/index.html"},
+		{Path: "private.html", Node: hidden},
+		{Path: "style.css"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []URL{
+		{Loc: "https://example.com/", LastMod: modified},
+		{Loc: "https://example.com/blog/"},
+		{Loc: "https://example.com/about.html"},
+		{Loc: "https://example.com/notes/my%20notes.html"},
+		{Loc: "https://example.com/caf%C3%A9/"},
+	}, urls)
+
+	_, err = URLs("", nil)
+	assert.Error(t, err)
+}
+
+func TestWriteIndex(t *testing.T) {
+	defer func(n int) { maxURLs = n }(maxURLs)
+	maxURLs = 2
+
+	var urls []URL
+	for i := 0; i < 5; i++ {
+		urls = append(urls, URL{Loc: fmt.Sprintf("https://example.com/%d.html", i)})
+	}
+	fsys := afero.NewMemMapFs()
+	require.NoError(t, Write(fsys, "https://example.com", urls))
+
+	index, err := afero.ReadFile(fsys, File)
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "<sitemapindex")
+	assert.Contains(t, string(index), "<loc>https://example.com/sitemap-3.xml</loc>")
+
+	last, err := afero.ReadFile(fsys, "sitemap-3.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(last), "<loc>https://example.com/4.html</loc>")
+	assert.NotContains(t, string(last), "3.html")
+}
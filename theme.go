@@ -0,0 +1,180 @@
+package sgunk
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// themeManifestFile declares a theme's parent. It is read from the root of
+// every theme directory, if present.
+const themeManifestFile = "theme.yml"
+
+type ThemeManifest struct {
+	Name string `yaml:"name"`
+	// Parent is the theme this one extends. Files missing from this theme
+	// are looked up in its parent. Themes in directories name the
+	// directory of their parent, relative to their own; registered themes
+	// name another registered theme.
+	Parent string `yaml:"parent"`
+}
+
+func readThemeManifest(fsys fs.FS, name string) (*ThemeManifest, error) {
+	var manifest ThemeManifest
+	b, err := fs.ReadFile(fsys, themeManifestFile)
+	if os.IsNotExist(err) {
+		return &manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(b, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(name, themeManifestFile), err)
+	}
+	return &manifest, nil
+}
+
+// Theme is a theme distributed as a Go package, typically embedded with
+// embed.FS. Projects use it by name, with theme.name in the project config.
+type Theme interface {
+	Name() string
+	FS() fs.FS
+}
+
+type theme struct {
+	name string
+	fsys fs.FS
+}
+
+func (t *theme) Name() string { return t.name }
+func (t *theme) FS() fs.FS    { return t.fsys }
+
+// NewTheme returns a theme named name, usually the import path of the
+// package providing it, with its files at the root of fsys. Use fs.Sub to
+// drop the directory an embed.FS keeps files under.
+func NewTheme(name string, fsys fs.FS) Theme {
+	return &theme{name: name, fsys: fsys}
+}
+
+// WithThemes registers themes that the project config can use by name.
+func WithThemes(themes ...Theme) ProjectOptionFunc {
+	return func(p *Project) error {
+		for _, t := range themes {
+			p.themes[t.Name()] = t
+		}
+		return nil
+	}
+}
+
+// WithThemeFS uses fsys as the project's theme, in place of theme.name.
+// Theme directories from the config still take precedence over it.
+func WithThemeFS(fsys fs.FS) ProjectOptionFunc {
+	return func(p *Project) error {
+		p.themeBase = fsys
+		return nil
+	}
+}
+
+// registeredThemes returns the file systems of the theme named name and
+// its chain of registered parents, from highest to lowest precedence.
+func (p *Project) registeredThemes(name string) ([]fs.FS, error) {
+	var layers []fs.FS
+	chain := make(map[string]bool)
+	for name != "" {
+		if chain[name] {
+			return nil, fmt.Errorf("theme %s inherits from itself", name)
+		}
+		chain[name] = true
+		t, ok := p.themes[name]
+		if !ok {
+			return nil, fmt.Errorf("no known theme '%s'", name)
+		}
+		layers = append(layers, t.FS())
+
+		manifest, err := readThemeManifest(t.FS(), name)
+		if err != nil {
+			return nil, err
+		}
+		name = manifest.Parent
+	}
+	return layers, nil
+}
+
+// ThemeDirs returns the theme directories templates and static files are
+// looked up in, from highest to lowest precedence: the theme dir, then each
+// of theme.layers, each followed by its chain of parents. It is only valid
+// after Configure.
+func (p *Project) ThemeDirs() ([]string, error) {
+	roots := []string{p.ThemeDir()}
+	for _, layer := range p.config.Theme.Layers {
+		roots = append(roots, filepath.Join(p.workDir, layer))
+	}
+
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, dir := range roots {
+		chain := make(map[string]bool)
+		for dir != "" {
+			dir = filepath.Clean(dir)
+			if chain[dir] {
+				return nil, fmt.Errorf("theme %s inherits from itself", dir)
+			}
+			chain[dir] = true
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+
+			manifest, err := readThemeManifest(afero.NewIOFS(afero.NewBasePathFs(p.rootFS, dir)), dir)
+			if err != nil {
+				return nil, err
+			}
+			if manifest.Parent == "" {
+				break
+			}
+			dir = filepath.Join(dir, manifest.Parent)
+		}
+	}
+	return dirs, nil
+}
+
+// themeFS returns a read-only view of the theme directories, followed by
+// the registered theme or the file system set with WithThemeFS, in which
+// each file is read from the first layer that has it.
+func (p *Project) themeFS() (afero.Fs, error) {
+	dirs, err := p.ThemeDirs()
+	if err != nil {
+		return nil, err
+	}
+	var layers []afero.Fs
+	for _, dir := range dirs {
+		layers = append(layers, afero.NewBasePathFs(p.rootFS, dir))
+	}
+	switch {
+	case p.themeBase != nil:
+		layers = append(layers, afero.FromIOFS{FS: p.themeBase})
+	case p.config.Theme.Name != "":
+		themes, err := p.registeredThemes(p.config.Theme.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, fsys := range themes {
+			layers = append(layers, afero.FromIOFS{FS: fsys})
+		}
+	}
+
+	var fsys afero.Fs
+	for i := len(layers) - 1; i >= 0; i-- {
+		layer := layers[i]
+		if fsys == nil {
+			fsys = layer
+			continue
+		}
+		fsys = afero.NewCopyOnWriteFs(fsys, layer)
+	}
+	return afero.NewReadOnlyFs(fsys), nil
+}
@@ -0,0 +1,106 @@
+package sgunk_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/connormckelvey/sgunk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestThemeLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"project.yml":   "name: test\ntheme:\n  layers: [vendor/base]\n",
+		"site/index.md": "---\npage:\n    title: Home\n    template: page.html\n---\n\nhello\n",
+		// The project theme extends company, which overrides base.
+		"theme/theme.yml":             "parent: ../company\n",
+		"theme/layout.html":           "<main><% $outlet %></main>",
+		"company/page.html":           "---\ntemplate: layout.html\n---\n<article><% $outlet %></article>",
+		"company/static/company.css":  "company",
+		"vendor/base/layout.html":     "<base-layout/>",
+		"vendor/base/page.html":       "<base-page/>",
+		"vendor/base/static/base.css": "base",
+	})
+
+	project := sgunk.New(sgunk.WithWorkDir(dir))
+	require.NoError(t, project.Configure())
+	dirs, err := project.ThemeDirs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "theme"),
+		filepath.Join(dir, "company"),
+		filepath.Join(dir, "vendor/base"),
+	}, dirs)
+
+	require.NoError(t, project.Generate(context.Background()))
+	b, err := os.ReadFile(filepath.Join(dir, "_build", "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<main><article><p>hello</p>\n</article></main>", string(b))
+	for _, name := range []string{"company.css", "base.css"} {
+		_, err := os.Stat(filepath.Join(dir, "_build", name))
+		assert.NoError(t, err, name)
+	}
+
+	writeFiles(t, dir, map[string]string{"company/theme.yml": "parent: ../theme\n"})
+	_, err = project.ThemeDirs()
+	assert.Error(t, err)
+}
+
+func TestRegisteredTheme(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"project.yml":       "name: test\ntheme:\n  name: example.com/acme\n",
+		"site/index.md":     "---\npage:\n    template: page.html\n---\n\nhello\n",
+		"theme/layout.html": "<local><% $outlet %></local>",
+	})
+
+	acme := fstest.MapFS{
+		"theme.yml":    {Data: []byte("parent: example.com/base\n")},
+		"page.html":    {Data: []byte("---\ntemplate: layout.html\n---\n<acme><% $outlet %></acme>")},
+		"static/a.css": {Data: []byte("acme")},
+	}
+	base := fstest.MapFS{
+		"layout.html": {Data: []byte("<base><% $outlet %></base>")},
+		"page.html":   {Data: []byte("<base-page/>")},
+	}
+
+	project := sgunk.New(
+		sgunk.WithWorkDir(dir),
+		sgunk.WithThemes(
+			sgunk.NewTheme("example.com/acme", acme),
+			sgunk.NewTheme("example.com/base", base),
+		),
+	)
+	require.NoError(t, project.Generate(context.Background()))
+	b, err := os.ReadFile(filepath.Join(dir, "_build", "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<local><acme><p>hello</p>\n</acme></local>", string(b))
+	_, err = os.Stat(filepath.Join(dir, "_build", "a.css"))
+	assert.NoError(t, err)
+
+	project = sgunk.New(
+		sgunk.WithWorkDir(dir),
+		sgunk.WithThemeFS(base),
+	)
+	require.NoError(t, project.Generate(context.Background()))
+	b, err = os.ReadFile(filepath.Join(dir, "_build", "index.html"))
+	require.NoError(t, err)
+	assert.Equal(t, "<base-page/>", string(b))
+
+	project = sgunk.New(sgunk.WithWorkDir(dir))
+	assert.ErrorContains(t, project.Generate(context.Background()), "no known theme")
+}
@@ -0,0 +1,19 @@
+package tree
+
+const AssetNodeKind = NodeKind("asset")
+
+// Asset is a file that is copied to the build directory as is, such as an
+// image or a stylesheet.
+type Asset struct {
+	BaseNode
+}
+
+func NewAsset(path string) *Asset {
+	return &Asset{
+		BaseNode: NewBaseNode(path, false),
+	}
+}
+
+func (*Asset) Kind() NodeKind {
+	return AssetNodeKind
+}
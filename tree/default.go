@@ -1,5 +1,10 @@
 package tree
 
+import (
+	"path"
+	"path/filepath"
+)
+
 const DefaultNodeKind = NodeKind("site")
 
 type DefaultPage struct {
@@ -18,6 +23,19 @@ func NewDefaultPage(path string, parts PageNameParts) *DefaultPage {
 	}
 }
 
+// URL returns the root relative URL of the page. Index pages are published
+// at the URL of their directory.
+func (p *DefaultPage) URL() string {
+	dir := path.Join("/", filepath.ToSlash(filepath.Dir(p.Path())))
+	if p.Parts.Slug != "index" {
+		return path.Join(dir, p.Parts.Slug+".html")
+	}
+	if dir == "/" {
+		return dir
+	}
+	return dir + "/"
+}
+
 type DefaultDir struct {
 	BaseNode
 }
@@ -16,3 +16,17 @@ type Node interface {
 	GetAttrs(key string) (map[string]any, bool)
 	Attributes() (map[string]map[string]any, error)
 }
+
+// Page is implemented by nodes published as a page of their own.
+type Page interface {
+	Node
+	// URL returns the root relative URL the page is published at.
+	URL() string
+}
+
+// Generated is implemented by nodes that have no file in the site, such as
+// pages built from data. Their source is rendered in place of a file.
+type Generated interface {
+	Node
+	Source() []byte
+}
@@ -7,6 +7,8 @@ type PageFrontMatter struct {
 	Meta     []*PageMetaValue  `yaml:"meta" mapstructure:"meta"`
 	Links    []*PageLinksValue `yaml:"links" mapstructure:"links"`
 	Template string            `yaml:"template" mapstructure:"template"`
+	// Sitemap set to false leaves the page out of the sitemap.
+	Sitemap *bool `yaml:"sitemap" mapstructure:"sitemap"`
 }
 
 type PageMetaValue struct {
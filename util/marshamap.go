@@ -27,8 +27,8 @@ func MarshalMap(v any) (map[string]any, error) {
 	case reflect.Pointer:
 		return MarshalMap(reflect.ValueOf(v).Elem().Interface())
 	case reflect.Map:
-		if rt.Key().Kind() != reflect.String {
-			return marshalMap(reflect.ValueOf(rt))
+		if rt.Key().Kind() == reflect.String {
+			return marshalMap(reflect.ValueOf(v))
 		}
 	}
 	return nil, errors.New("input must be a struct, struct pointer, map[string]any, or a MapMarshaler")
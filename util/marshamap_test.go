@@ -35,3 +35,9 @@ func TestMarshalMap(t *testing.T) {
 
 	spew.Dump(v)
 }
+
+func TestMarshalMapOfStrings(t *testing.T) {
+	v, err := MarshalMap(map[string]any{"name": "widget", "price": 10})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "widget", "price": 10}, v)
+}
@@ -0,0 +1,25 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify lowercases s and joins its runs of letters and digits with
+// hyphens, so "Why LLMs aren't great!" becomes "why-llms-aren-t-great".
+func Slugify(s string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		pendingHyphen = true
+	}
+	return b.String()
+}
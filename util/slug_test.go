@@ -0,0 +1,15 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "chatgpt", Slugify("ChatGPT"))
+	assert.Equal(t, "chatgpt", Slugify(" chatgpt "))
+	assert.Equal(t, "why-llms-aren-t-so-great", Slugify("Why LLMs aren't so great!"))
+	assert.Equal(t, "go-1-22", Slugify("Go 1.22"))
+	assert.Equal(t, "", Slugify("--"))
+}